@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
-	// "strconv" // Not strictly needed for this basic version, but good for future if parsing ints, bools
 )
 
 // Config holds application configuration values.
@@ -14,6 +14,17 @@ type Config struct {
 	JWTSecret    string
 	DatabasePath string
 	ServerPort   string
+
+	// Uploaded files
+	StorageDriver    string // "local" or "s3"
+	StorageLocalPath string
+	S3Endpoint       string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3Bucket         string
+	S3Region         string
+	S3UseSSL         bool
+	MaxUploadMB      int64
 }
 
 // AppConfig is a global variable holding the application configuration.
@@ -51,6 +62,19 @@ func LoadConfig() *Config {
 		JWTSecret:    getEnv("JWT_SECRET", "flashcard_secret"),
 		DatabasePath: dsn,
 		ServerPort:   port,
+
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalPath: getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:      os.Getenv("S3_SECRET_KEY"),
+		S3Bucket:         getEnv("S3_BUCKET", "stock-management"),
+		S3Region:         os.Getenv("S3_REGION"),
+		S3UseSSL:         getEnv("S3_USE_SSL", "false") == "true",
+		MaxUploadMB:      5,
+	}
+	if mb, err := strconv.ParseInt(os.Getenv("MAX_UPLOAD_MB"), 10, 64); err == nil && mb > 0 {
+		AppConfig.MaxUploadMB = mb
 	}
 	return AppConfig
 }
@@ -15,20 +15,39 @@ func InitDatabase(dbPath string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// unaccent sert à la recherche d'articles insensible aux accents
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS unaccent").Error; err != nil {
+		return nil, err
+	}
+
 	// Migration automatique de tous les modèles
 	err = db.AutoMigrate(
 		&models.Account{},
 		&models.User{},
 		&models.Shop{},
-		&models.Article{}, &models.Category{}, &models.Brand{},
-		&models.StockLevel{}, &models.StockMovement{},
-		&models.Subscription{}, &models.Supplier{},
+		&models.Article{}, &models.Category{}, &models.Brand{}, &models.ArticleBarcode{}, &models.ArticleUnit{}, &models.KitComponent{}, &models.AttributeDefinition{}, &models.CodeSequence{},
+		&models.StockLevel{}, &models.StockMovement{}, &models.CostLayer{},
+		&models.Subscription{}, &models.Supplier{}, &models.ArticleSupplier{},
 		&models.PurchaseOrder{}, &models.PurchaseOrderItem{},
 		&models.StockTransfer{},
+		&models.ProductionOrder{}, &models.ProductionInput{}, &models.ProductionOutput{},
+		&models.PriceList{}, &models.PriceListItem{}, &models.PriceChange{}, &models.ScheduledPrice{},
 	)
 	if err != nil {
 		return nil, err
 	}
+	// Noms de catégories et de marques uniques par compte, sans tenir compte de la casse
+	// (remplace les index sensibles à la casse des anciennes versions)
+	for _, stmt := range []string{
+		"DROP INDEX IF EXISTS idx_categories_account_name",
+		"DROP INDEX IF EXISTS idx_brands_account_name",
+		"CREATE UNIQUE INDEX IF NOT EXISTS " + models.CategoryNameIndex + " ON categories (account_id, LOWER(name))",
+		"CREATE UNIQUE INDEX IF NOT EXISTS " + models.BrandNameIndex + " ON brands (account_id, LOWER(name))",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, err
+		}
+	}
 	// Seed idempotent des données nécessaires (rôles, etc.)
 	if err := SeedInitialData(db); err != nil {
 		return nil, err
@@ -1,40 +1,135 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreateArticleRequest struct {
-	Name         string     `json:"name" binding:"required"`
+	Name         string                 `json:"name" binding:"required"`
+	Code         string                 `json:"code"`
+	Description  string                 `json:"description"`
+	CategoryID   *uuid.UUID             `json:"category_id"`
+	BrandID      *uuid.UUID             `json:"brand_id"`
+	MinThreshold float64                `json:"min_threshold" binding:"gte=0"`
+	Price        float64                `json:"price"`
+	CostPrice    float64                `json:"cost_price"`
+	StockUnit    string                 `json:"stock_unit"`  // Default "unit"
+	DecimalQty   bool                   `json:"decimal_qty"` // Weighed goods
+	InitialStock float64                `json:"initial_stock"`
+	ShopID       *uuid.UUID             `json:"shop_id"`
+	Barcodes     []string               `json:"barcodes"`
+	Attributes   map[string]interface{} `json:"attributes"` // Custom attribute values by key
+}
+
+type UpdateArticleRequest struct {
+	Name         string                 `json:"name" binding:"required"`
+	Description  string                 `json:"description"`
+	MinThreshold float64                `json:"min_threshold" binding:"gte=0"`
+	Price        float64                `json:"price"`
+	CostPrice    *float64               `json:"cost_price"` // Omitted keeps the cost
+	Attributes   map[string]interface{} `json:"attributes"` // Replaces all the values; omitted keeps them
+}
+
+type AddBarcodeRequest struct {
+	Code      string `json:"code"` // Empty generates an internal EAN-13
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type BarcodePrefixRequest struct {
+	Prefix string `json:"prefix" binding:"required,numeric,min=2,max=7"`
+}
+
+type CodeSchemeRequest struct {
+	Prefix     string `json:"prefix" binding:"required,alphanum,max=10"`
+	Separator  string `json:"separator" binding:"omitempty,oneof=- / . _"`
+	Digits     int    `json:"digits" binding:"required,min=3,max=10"`
+	CheckDigit bool   `json:"check_digit"`
+}
+
+type LabelItemRequest struct {
+	ArticleID uuid.UUID `json:"article_id" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,gt=0"`
+}
+
+type PrintLabelsRequest struct {
+	Items     []LabelItemRequest `json:"items" binding:"required,min=1,dive"`
+	Layout    string             `json:"layout"`    // One of services.LabelLayouts, default a4-21
+	Symbology string             `json:"symbology"` // code128 (default) or ean13
+	Skip      int                `json:"skip"`      // Positions already used on the first sheet
+}
+
+type ZPLLabelsRequest struct {
+	Items          []LabelItemRequest `json:"items" binding:"required,min=1,dive"`
+	Symbology      string             `json:"symbology"`
+	WidthMM        float64            `json:"width_mm"`        // Default 50
+	HeightMM       float64            `json:"height_mm"`       // Default 30
+	DPI            int                `json:"dpi"`             // 203 (default), 300 or 600
+	ShopID         *uuid.UUID         `json:"shop_id"`         // Print on this shop's printer
+	PrinterAddress string             `json:"printer_address"` // Or on this host[:port]
+}
+
+type PrinterRequest struct {
+	PrinterAddress string `json:"printer_address"`
+}
+
+type CreateVariantRequest struct {
 	Code         string     `json:"code"`
-	Description  string     `json:"description"`
-	CategoryID   *uuid.UUID `json:"category_id"`
-	BrandID      *uuid.UUID `json:"brand_id"`
-	MinThreshold int        `json:"min_threshold"`
-	Price        float64    `json:"price"`
-	InitialStock int        `json:"initial_stock"`
+	Size         string     `json:"size"`
+	Color        string     `json:"color"`
+	Price        *float64   `json:"price"` // Overrides the parent price when set
+	CostPrice    *float64   `json:"cost_price"`
+	MinThreshold *float64   `json:"min_threshold"`
+	InitialStock float64    `json:"initial_stock"`
 	ShopID       *uuid.UUID `json:"shop_id"`
 }
 
-type UpdateArticleRequest struct {
-	Name         string  `json:"name" binding:"required"`
-	Description  string  `json:"description"`
-	MinThreshold int     `json:"min_threshold"`
-	Price        float64 `json:"price"`
+type GenerateVariantsRequest struct {
+	Sizes  []string `json:"sizes"`
+	Colors []string `json:"colors"`
 }
 
 type RecordMovementRequest struct {
-	ShopID    uuid.UUID `json:"shop_id" binding:"required"`
+	ShopID      uuid.UUID  `json:"shop_id" binding:"required"`
+	ArticleID   uuid.UUID  `json:"article_id" binding:"required"`
+	Type        string     `json:"type" binding:"required"` // in, out, adjust
+	Qty         float64    `json:"qty" binding:"required"`
+	Unit        string     `json:"unit"`      // One of the article's units (its sales_unit to sell by it); stock unit by default
+	UnitCost    float64    `json:"unit_cost"` // Purchase cost of one Unit of an "in" movement
+	Reason      string     `json:"reason"`
+	DeviceID    string     `json:"device_id"`
+	PriceListID *uuid.UUID `json:"price_list_id"` // Price list of an "out" movement (sale), the shop's by default
+}
+
+type SchedulePriceRequest struct {
+	Price       float64   `json:"price" binding:"gte=0"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"`
+}
+
+type PriceListRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	ShopID    *uuid.UUID `json:"shop_id"`
+	ValidFrom *time.Time `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+	IsActive  *bool      `json:"is_active"` // Defaults to true
+}
+
+type PriceListItemRequest struct {
 	ArticleID uuid.UUID `json:"article_id" binding:"required"`
-	Type      string    `json:"type" binding:"required"` // in, out, adjust
-	Qty       int       `json:"qty" binding:"required"`
-	Reason    string    `json:"reason"`
-	DeviceID  string    `json:"device_id"`
+	Price     float64   `json:"price" binding:"gte=0"`
+}
+
+type PriceListItemsRequest struct {
+	Items []PriceListItemRequest `json:"items" binding:"required,min=1,dive"`
 }
 
 type TransferStockRequest struct {
 	FromShopID uuid.UUID `json:"from_shop_id" binding:"required"`
 	ToShopID   uuid.UUID `json:"to_shop_id" binding:"required"`
 	ArticleID  uuid.UUID `json:"article_id" binding:"required"`
-	Qty        int       `json:"qty" binding:"required"`
+	Qty        float64   `json:"qty" binding:"required"`
+	Unit       string    `json:"unit"` // One of the article's units; stock unit by default
 	Reason     string    `json:"reason"`
 	DeviceID   string    `json:"device_id"`
 }
@@ -69,6 +164,63 @@ type CreateShopRequest struct {
 	Location string `json:"location"`
 }
 
+type CatalogEntryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CategoryRequest struct {
+	Name       string     `json:"name" binding:"required"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	CodePrefix string     `json:"code_prefix" binding:"omitempty,alphanum,max=10"`
+}
+
+type SupplierRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Contact string `json:"contact"`
+	Email   string `json:"email"`
+	Address string `json:"address"`
+}
+
+type ArticleSupplierRequest struct {
+	SupplierRef       string   `json:"supplier_ref"`
+	LastPurchasePrice *float64 `json:"last_purchase_price" binding:"omitempty,gte=0"` // Omitted keeps the price of the last receipt
+	MinOrderQty       float64  `json:"min_order_qty" binding:"gte=0"`                 // In the stock unit, like the price
+	PackSize          int      `json:"pack_size" binding:"gte=0"`
+	LeadTimeDays      int      `json:"lead_time_days" binding:"gte=0"`
+	IsPreferred       bool     `json:"is_preferred"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ArticleID uuid.UUID `json:"article_id" binding:"required"`
+	Quantity  float64   `json:"quantity" binding:"required,gt=0"`
+	Unit      string    `json:"unit"`                       // One of the article's units; its purchase unit by default
+	UnitPrice float64   `json:"unit_price" binding:"gte=0"` // Per Unit; 0 uses the supplier's last purchase price
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID uuid.UUID                  `json:"supplier_id" binding:"required"`
+	OrderDate  *time.Time                 `json:"order_date"`
+	Notes      string                     `json:"notes"`
+	Items      []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReceptionLineRequest struct {
+	ArticleID uuid.UUID `json:"article_id" binding:"required"`
+	Qty       float64   `json:"qty" binding:"required,gt=0"` // In the unit of the order line
+}
+
+type ReceivePurchaseOrderRequest struct {
+	ShopID           uuid.UUID              `json:"shop_id" binding:"required"`
+	Lines            []ReceptionLineRequest `json:"lines" binding:"required,min=1,dive"`
+	AllowOverReceipt bool                   `json:"allow_over_receipt"`
+	DeviceID         string                 `json:"device_id"`
+}
+
+type ReorderRequest struct {
+	ShopID   *uuid.UUID `json:"shop_id"`
+	Coverage float64    `json:"coverage"` // Target level as a multiple of MinThreshold (default 2)
+}
+
 type InviteUserRequest struct {
 	FirstName string     `json:"first_name" binding:"required"`
 	LastName  string     `json:"last_name" binding:"required"`
@@ -92,3 +244,90 @@ type UpdateThemeRequest struct {
 	PrimaryColor    string `json:"primary_color"`
 	BackgroundImage string `json:"background_image"`
 }
+
+type ArticleUnitRequest struct {
+	Name   string  `json:"name" binding:"required"`
+	Factor float64 `json:"factor" binding:"required,gt=0"` // Stock units in one unit
+}
+
+type UnitSettingsRequest struct {
+	StockUnit    string `json:"stock_unit"`
+	DecimalQty   bool   `json:"decimal_qty"`
+	PurchaseUnit string `json:"purchase_unit"`
+	SalesUnit    string `json:"sales_unit"`
+}
+
+type KitComponentRequest struct {
+	ArticleID uuid.UUID `json:"article_id" binding:"required"`
+	Qty       float64   `json:"qty" binding:"required,gt=0"` // Stock units of the component per kit
+}
+
+type KitComponentsRequest struct {
+	Components []KitComponentRequest `json:"components" binding:"dive"` // Empty turns the kit back into a regular article
+}
+
+type ProductionInputRequest struct {
+	ArticleID  uuid.UUID `json:"article_id" binding:"required"`
+	PlannedQty float64   `json:"planned_qty" binding:"required,gt=0"` // In the stock unit
+}
+
+type ProductionOutputRequest struct {
+	ArticleID  uuid.UUID `json:"article_id" binding:"required"`
+	PlannedQty float64   `json:"planned_qty" binding:"required,gt=0"` // In the stock unit
+	CostShare  float64   `json:"cost_share" binding:"gte=0"`          // 0 on every output spreads the cost by quantity
+}
+
+type ProductionOrderRequest struct {
+	ShopID    uuid.UUID                 `json:"shop_id" binding:"required"`
+	Notes     string                    `json:"notes"`
+	ExtraCost float64                   `json:"extra_cost" binding:"gte=0"`
+	Inputs    []ProductionInputRequest  `json:"inputs" binding:"required,min=1,dive"`
+	Outputs   []ProductionOutputRequest `json:"outputs" binding:"required,min=1,dive"`
+}
+
+type ProductionActualRequest struct {
+	ArticleID uuid.UUID `json:"article_id" binding:"required"`
+	Qty       float64   `json:"qty" binding:"gte=0"`       // Consumed (waste included) or produced, in the stock unit
+	WasteQty  float64   `json:"waste_qty" binding:"gte=0"` // Inputs only
+}
+
+type CompleteProductionRequest struct {
+	Inputs   []ProductionActualRequest `json:"inputs" binding:"dive"`  // Lines left out are consumed as planned
+	Outputs  []ProductionActualRequest `json:"outputs" binding:"dive"` // Lines left out are produced as planned
+	DeviceID string                    `json:"device_id"`
+}
+
+type AttributeDefinitionRequest struct {
+	Key      string   `json:"key"`  // Required on creation; cannot change
+	Type     string   `json:"type"` // text, number, enum or date; cannot change
+	Label    string   `json:"label"`
+	Options  []string `json:"options"` // Enum values
+	Required bool     `json:"required"`
+	Position int      `json:"position"`
+}
+
+type BulkArticleFilterRequest struct {
+	ShopID     *uuid.UUID        `json:"shop_id"`
+	CategoryID *uuid.UUID        `json:"category_id"` // Includes the sub-categories
+	BrandID    *uuid.UUID        `json:"brand_id"`
+	Search     string            `json:"search"`
+	Stock      string            `json:"stock"`      // low or out
+	Attributes map[string]string `json:"attributes"` // Custom attribute values by key
+}
+
+type PriceAdjustmentRequest struct {
+	Mode     string  `json:"mode" binding:"required,oneof=set percent amount"`
+	Value    float64 `json:"value"`                                              // New price, percentage or amount added
+	RoundTo  float64 `json:"round_to" binding:"gte=0"`                           // Rounding step, e.g. 25 FCFA
+	Rounding string  `json:"rounding" binding:"omitempty,oneof=nearest up down"` // Default nearest
+}
+
+type BulkUpdateArticlesRequest struct {
+	ArticleIDs   []uuid.UUID               `json:"article_ids"`
+	Filter       *BulkArticleFilterRequest `json:"filter"` // Used when article_ids is empty; {} selects every article
+	CategoryID   *uuid.UUID                `json:"category_id"`
+	BrandID      *uuid.UUID                `json:"brand_id"`
+	MinThreshold *float64                  `json:"min_threshold" binding:"omitempty,gte=0"`
+	Price        *PriceAdjustmentRequest   `json:"price"`
+	DryRun       bool                      `json:"dry_run"` // Preview the changes without saving them
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"stock_management/dto"
 	"stock_management/models"
 	"stock_management/services"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ArticleHandler struct {
@@ -54,9 +60,20 @@ func (h *ArticleHandler) CreateArticle(c *gin.Context) {
 		BrandID:      req.BrandID,
 		MinThreshold: req.MinThreshold,
 		Price:        req.Price,
+		CostPrice:    req.CostPrice,
+		StockUnit:    strings.TrimSpace(req.StockUnit),
+		DecimalQty:   req.DecimalQty,
+		Attributes:   req.Attributes,
+	}
+	for _, code := range req.Barcodes {
+		article.Barcodes = append(article.Barcodes, models.ArticleBarcode{Code: code})
 	}
 
 	if err := h.Service.CreateArticle(article, req.InitialStock, shopID, userID); err != nil {
+		if isUnitError(err) || errors.Is(err, services.ErrInvalidAttribute) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -100,15 +117,66 @@ func (h *ArticleHandler) ListArticles(c *gin.Context) {
 		}
 	}
 
-	articles, err := h.Service.GetArticlesByAccount(accountID, shopID)
+	// Products with their variant matrix and per-shop stock
+	if c.Query("group") == "variants" {
+		products, err := h.Service.GetProductsWithVariants(accountID, shopID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, products)
+		return
+	}
+
+	filter := services.ArticleFilter{
+		ShopID: shopID,
+		Search: c.Query("search"),
+		Stock:  c.Query("stock"),
+		Sort:   c.Query("sort"),
+		Desc:   c.Query("order") == "desc",
+		Cursor: c.Query("cursor"),
+	}
+	filter.Attributes = attributeFilters(c)
+	for param, target := range map[string]**uuid.UUID{"category_id": &filter.CategoryID, "brand_id": &filter.BrandID} {
+		if value := c.Query(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*target = &id
+		}
+	}
+
+	// Without page, page_size or cursor the whole list is returned as a plain array
+	paginated := c.Query("page") != "" || c.Query("page_size") != "" || filter.Cursor != ""
+	if paginated {
+		filter.Page, _ = strconv.Atoi(c.Query("page"))
+		filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+		if filter.PageSize <= 0 {
+			filter.PageSize = 50
+		}
+	}
+
+	page, err := h.Service.ListArticles(accountID, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidArticleFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, articles)
+	if !paginated {
+		c.JSON(http.StatusOK, page.Items)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
+// ImportArticles imports a CSV or .xlsx file sent in the "file" field of a multipart form.
+// Form fields: dry_run=true to only validate, shop_id for the "stock" column.
 func (h *ArticleHandler) ImportArticles(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -126,16 +194,87 @@ func (h *ArticleHandler) ImportArticles(c *gin.Context) {
 	accountIDStr := c.GetString("account_id")
 	accountID, _ := uuid.Parse(accountIDStr)
 
-	count, err := h.Service.ImportArticlesFromCSV(accountID, src)
+	opts, ok := importOptions(c)
+	if !ok {
+		return
+	}
+
+	var report *services.ImportReport
+	if strings.HasSuffix(strings.ToLower(file.Filename), ".xlsx") {
+		report, err = h.Service.ImportArticlesFromXLSX(accountID, src, opts)
+	} else {
+		report, err = h.Service.ImportArticlesFromCSV(accountID, src, opts)
+	}
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportArticles downloads the catalog in the import layout, as an .xlsx workbook
+// or a CSV file with ?format=csv.
+func (h *ArticleHandler) ExportArticles(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if c.Query("format") == "csv" {
+		data, err := h.Service.ExportArticlesCSV(accountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=articles-"+time.Now().Format("20060102")+".csv")
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+		return
+	}
+
+	data, err := h.Service.ExportArticlesXLSX(accountID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "processed": count})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "Import successful",
-		"imported": count,
-	})
+	c.Header("Content-Disposition", "attachment; filename=articles-"+time.Now().Format("20060102")+".xlsx")
+	c.Data(http.StatusOK, services.XLSXContentType, data)
+}
+
+// importOptions reads the import form fields. Vendors can only import stock in their shop.
+func importOptions(c *gin.Context) (services.ImportOptions, bool) {
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	opts := services.ImportOptions{
+		DryRun: c.PostForm("dry_run") == "true",
+		UserID: userID,
+	}
+
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return opts, false
+		}
+		opts.ShopID = &id
+		opts.OnlyShopID = &id
+	} else if shopIDStr := c.PostForm("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return opts, false
+		}
+		opts.ShopID = &id
+	}
+	return opts, true
+}
+
+func respondImportError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidImportFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 func (h *ArticleHandler) UpdateArticle(c *gin.Context) {
@@ -160,12 +299,321 @@ func (h *ArticleHandler) UpdateArticle(c *gin.Context) {
 	article.Name = req.Name
 	article.Description = req.Description
 	article.MinThreshold = req.MinThreshold
-	article.Price = req.Price
+	if article.ParentID == nil || req.Price != article.Price {
+		article.HasPriceOverride = article.ParentID != nil
+		article.Price = req.Price
+	}
+	if req.CostPrice != nil {
+		article.CostPrice = *req.CostPrice
+	}
+	if req.Attributes != nil {
+		article.Attributes = req.Attributes
+	}
+
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
 
-	if err := h.Service.UpdateArticle(&article); err != nil {
+	if err := h.Service.UpdateArticle(&article, userID, models.PriceChangeManual); err != nil {
+		if errors.Is(err, services.ErrInvalidAttribute) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, article)
 }
+
+func (h *ArticleHandler) CreateVariant(c *gin.Context) {
+	var req dto.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	var shopID *uuid.UUID
+	if c.GetString("role") == "vendor" && c.GetString("shop_id") != "" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err == nil {
+			shopID = &id
+		}
+	} else if req.ShopID != nil {
+		shopID = req.ShopID
+	}
+
+	variant := &models.Article{
+		AccountID: accountID,
+		Code:      req.Code,
+		Size:      req.Size,
+		Color:     req.Color,
+	}
+	if req.Price != nil {
+		variant.Price = *req.Price
+		variant.HasPriceOverride = true
+	}
+	if req.CostPrice != nil {
+		variant.CostPrice = *req.CostPrice
+	}
+	if req.MinThreshold != nil {
+		variant.MinThreshold = *req.MinThreshold
+	}
+
+	if err := h.Service.CreateVariant(parentID, variant, req.InitialStock, shopID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// GenerateVariants creates the sizes × colours matrix of a product in one call.
+func (h *ArticleHandler) GenerateVariants(c *gin.Context) {
+	var req dto.GenerateVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	variants, err := h.Service.GenerateVariants(accountID, parentID, req.Sizes, req.Colors, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"created": len(variants), "variants": variants})
+}
+
+func (h *ArticleHandler) ListBarcodes(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	barcodes, err := h.Service.GetBarcodes(accountID, articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, barcodes)
+}
+
+func (h *ArticleHandler) AddBarcode(c *gin.Context) {
+	var req dto.AddBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	barcode, err := h.Service.AddBarcode(accountID, articleID, req.Code, req.IsPrimary)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		case errors.Is(err, services.ErrBarcodeTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, barcode)
+}
+
+func (h *ArticleHandler) RemoveBarcode(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+	barcodeID, err := uuid.Parse(c.Param("barcode_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid barcode id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.RemoveBarcode(accountID, articleID, barcodeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Barcode not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "barcode removed successfully"})
+}
+
+// GetByBarcode is hit by handheld scanners: it returns the article and its stock,
+// restricted to the vendor's shop (or ?shop_id= for other roles).
+func (h *ArticleHandler) GetByBarcode(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shopID *uuid.UUID
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return
+		}
+		shopID = &id
+	} else if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err == nil {
+			shopID = &id
+		}
+	}
+
+	lookup, err := h.Service.FindByBarcode(accountID, c.Param("code"), shopID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No article matches this barcode"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lookup)
+}
+
+func (h *ArticleHandler) GetPriceHistory(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	changes, err := h.Service.GetPriceHistory(accountID, articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+func (h *ArticleHandler) SchedulePrice(c *gin.Context) {
+	var req dto.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	scheduled, err := h.Service.SchedulePriceChange(accountID, articleID, userID, req.Price, req.EffectiveAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, scheduled)
+}
+
+// ListScheduledPrices lists the scheduled price changes of the article in :id, or of
+// the whole account on /scheduled-prices. Filter with ?status=pending|applied|cancelled.
+func (h *ArticleHandler) ListScheduledPrices(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var articleID uuid.UUID
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+			return
+		}
+		articleID = id
+	}
+
+	scheduled, err := h.Service.GetScheduledPrices(accountID, articleID, models.ScheduledPriceStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
+
+func (h *ArticleHandler) CancelScheduledPrice(c *gin.Context) {
+	scheduledID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled price id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.CancelScheduledPrice(accountID, scheduledID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled price change not found"})
+		case errors.Is(err, services.ErrScheduledPriceNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "scheduled price change cancelled"})
+}
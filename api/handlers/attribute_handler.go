@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListAttributes returns the custom attribute definitions of the account.
+func (h *ArticleHandler) ListAttributes(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	defs, err := h.Service.GetAttributeDefinitions(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, defs)
+}
+
+func (h *ArticleHandler) CreateAttribute(c *gin.Context) {
+	var req dto.AttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	def := &models.AttributeDefinition{
+		AccountID: accountID,
+		Key:       req.Key,
+		Label:     req.Label,
+		Type:      models.AttributeType(req.Type),
+		Options:   req.Options,
+		Required:  req.Required,
+		Position:  req.Position,
+	}
+	if err := h.Service.CreateAttributeDefinition(def); err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, def)
+}
+
+// UpdateAttribute changes the label, options, required flag and position of an
+// attribute; its key and type cannot change.
+func (h *ArticleHandler) UpdateAttribute(c *gin.Context) {
+	var req dto.AttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	defID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attribute id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	def, err := h.Service.UpdateAttributeDefinition(accountID, defID, &models.AttributeDefinition{
+		Label:    req.Label,
+		Options:  req.Options,
+		Required: req.Required,
+		Position: req.Position,
+	})
+	if err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, def)
+}
+
+// DeleteAttribute removes an attribute definition and its values from every article.
+func (h *ArticleHandler) DeleteAttribute(c *gin.Context) {
+	defID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attribute id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.DeleteAttributeDefinition(accountID, defID); err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// attributeFilters reads the attribute filters of ListArticles:
+// attr[key]=value, attr_min[key]=value and attr_max[key]=value.
+func attributeFilters(c *gin.Context) []services.AttributeFilter {
+	byKey := make(map[string]*services.AttributeFilter)
+	get := func(key string) *services.AttributeFilter {
+		if byKey[key] == nil {
+			byKey[key] = &services.AttributeFilter{Key: key}
+		}
+		return byKey[key]
+	}
+	for key, value := range c.QueryMap("attr") {
+		get(key).Value = value
+	}
+	for key, value := range c.QueryMap("attr_min") {
+		get(key).Min = value
+	}
+	for key, value := range c.QueryMap("attr_max") {
+		get(key).Max = value
+	}
+
+	filters := make([]services.AttributeFilter, 0, len(byKey))
+	for _, f := range byKey {
+		filters = append(filters, *f)
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Key < filters[j].Key })
+	return filters
+}
+
+func respondAttributeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+	case errors.Is(err, services.ErrInvalidAttribute):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
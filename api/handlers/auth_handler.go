@@ -254,3 +254,51 @@ func (h *AuthHandler) UpdateTheme(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "theme updated successfully"})
 }
+
+func (h *AuthHandler) UpdateBarcodePrefix(c *gin.Context) {
+	var req dto.BarcodePrefixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.GetString("role")
+	if role != string(models.RoleOwner) && role != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can change the barcode prefix"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.UpdateBarcodePrefix(accountID, req.Prefix); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "barcode prefix updated successfully"})
+}
+
+func (h *AuthHandler) UpdateCodeScheme(c *gin.Context) {
+	var req dto.CodeSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.GetString("role")
+	if role != string(models.RoleOwner) && role != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can change the article code scheme"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.UpdateCodeScheme(accountID, req.Prefix, req.Separator, req.Digits, req.CheckDigit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "code scheme updated successfully"})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BulkUpdateArticles applies a category, brand, min threshold or price change to a
+// list of articles or to every article matching a filter, in a single transaction.
+// With dry_run the report previews the changes without saving them. The filter of
+// vendors is restricted to their shop, as in ListArticles.
+func (h *ArticleHandler) BulkUpdateArticles(c *gin.Context) {
+	var req dto.BulkUpdateArticlesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	selection := services.BulkArticleSelection{ArticleIDs: req.ArticleIDs}
+	if req.Filter != nil {
+		filter := &services.ArticleFilter{
+			ShopID:     req.Filter.ShopID,
+			CategoryID: req.Filter.CategoryID,
+			BrandID:    req.Filter.BrandID,
+			Search:     req.Filter.Search,
+			Stock:      req.Filter.Stock,
+		}
+		for key, value := range req.Filter.Attributes {
+			filter.Attributes = append(filter.Attributes, services.AttributeFilter{Key: key, Value: value})
+		}
+		if c.GetString("role") == "vendor" {
+			id, err := uuid.Parse(c.GetString("shop_id"))
+			if err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+				return
+			}
+			filter.ShopID = &id
+		}
+		selection.Filter = filter
+	}
+
+	patch := services.BulkArticlePatch{
+		CategoryID:   req.CategoryID,
+		BrandID:      req.BrandID,
+		MinThreshold: req.MinThreshold,
+	}
+	if req.Price != nil {
+		patch.Price = &services.PriceAdjustment{
+			Mode:     req.Price.Mode,
+			Value:    req.Price.Value,
+			RoundTo:  req.Price.RoundTo,
+			Rounding: req.Price.Rounding,
+		}
+	}
+
+	report, err := h.Service.BulkUpdateArticles(accountID, userID, selection, patch, req.DryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBulkUpdate) || errors.Is(err, services.ErrInvalidArticleFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CatalogHandler struct {
+	Service *services.CatalogService
+}
+
+func NewCatalogHandler(s *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{Service: s}
+}
+
+// Categories
+
+func (h *CatalogHandler) CreateCategory(c *gin.Context) {
+	var req dto.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	category := &models.Category{AccountID: accountID, Name: req.Name, ParentID: req.ParentID, CodePrefix: req.CodePrefix}
+	if err := h.Service.CreateCategory(category); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// ListCategories returns a flat list, or the nested tree with ?tree=true.
+func (h *CatalogHandler) ListCategories(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if c.Query("tree") == "true" {
+		tree, err := h.Service.GetCategoryTree(accountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tree)
+		return
+	}
+
+	categories, err := h.Service.GetCategoriesByAccount(accountID, c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+func (h *CatalogHandler) UpdateCategory(c *gin.Context) {
+	var req dto.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	category, err := h.Service.GetCategory(accountID, categoryID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	category.Name = req.Name
+	category.ParentID = req.ParentID
+	category.CodePrefix = req.CodePrefix
+	if err := h.Service.UpdateCategory(category); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory accepts ?reassign_to=<category id> to move the articles first,
+// or ?reassign_to=none to leave them uncategorised.
+func (h *CatalogHandler) DeleteCategory(c *gin.Context) {
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	reassignTo, clear, ok := parseReassignTarget(c)
+	if !ok {
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.DeleteCategory(accountID, categoryID, reassignTo, clear); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted successfully"})
+}
+
+// Brands
+
+func (h *CatalogHandler) CreateBrand(c *gin.Context) {
+	var req dto.CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	brand := &models.Brand{AccountID: accountID, Name: req.Name}
+	if err := h.Service.CreateBrand(brand); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, brand)
+}
+
+func (h *CatalogHandler) ListBrands(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	brands, err := h.Service.GetBrandsByAccount(accountID, c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, brands)
+}
+
+func (h *CatalogHandler) UpdateBrand(c *gin.Context) {
+	var req dto.CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	brandID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid brand id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	brand, err := h.Service.GetBrand(accountID, brandID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Brand not found"})
+		return
+	}
+
+	brand.Name = req.Name
+	if err := h.Service.UpdateBrand(brand); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, brand)
+}
+
+// DeleteBrand accepts the same reassign_to parameter as DeleteCategory.
+func (h *CatalogHandler) DeleteBrand(c *gin.Context) {
+	brandID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid brand id"})
+		return
+	}
+
+	reassignTo, clear, ok := parseReassignTarget(c)
+	if !ok {
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.DeleteBrand(accountID, brandID, reassignTo, clear); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "brand deleted successfully"})
+}
+
+func parseReassignTarget(c *gin.Context) (*uuid.UUID, bool, bool) {
+	target := c.Query("reassign_to")
+	switch target {
+	case "":
+		return nil, false, true
+	case "none":
+		return nil, true, true
+	}
+	id, err := uuid.Parse(target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reassign_to id"})
+		return nil, false, false
+	}
+	return &id, false, true
+}
+
+// respondCatalogError maps catalog service errors to HTTP status codes.
+func respondCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDuplicateName), errors.Is(err, services.ErrEntryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"stock_management/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type DashboardHandler struct {
@@ -25,8 +27,20 @@ func (h *DashboardHandler) GetStats(c *gin.Context) {
 		shopID, _ = uuid.Parse(shopIDStr)
 	}
 
-	stats, err := h.Service.GetDashboardStats(accountID, shopID)
-	if err != nil {
+	categoryID := uuid.Nil
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		var err error
+		if categoryID, err = uuid.Parse(categoryIDStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+			return
+		}
+	}
+
+	stats, err := h.Service.GetDashboardStats(accountID, shopID, categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SetKitComponents replaces the bill of materials of a kit.
+func (h *ArticleHandler) SetKitComponents(c *gin.Context) {
+	var req dto.KitComponentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kitID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	components := make([]models.KitComponent, 0, len(req.Components))
+	for _, component := range req.Components {
+		components = append(components, models.KitComponent{ComponentID: component.ArticleID, Qty: component.Qty})
+	}
+
+	kit, err := h.Service.SetKitComponents(accountID, kitID, components)
+	if err != nil {
+		respondKitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+// GetKitAvailability returns how many kits the component stock of each shop can make.
+// Vendors only see their own shop.
+func (h *ArticleHandler) GetKitAvailability(c *gin.Context) {
+	kitID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shopID *uuid.UUID
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return
+		}
+		shopID = &id
+	} else if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return
+		}
+		shopID = &id
+	}
+
+	availability, err := h.Service.GetKitAvailability(accountID, kitID, shopID)
+	if err != nil {
+		respondKitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// isKitError reports whether err comes from a movement a kit cannot take or an invalid bill of materials.
+func isKitError(err error) bool {
+	return errors.Is(err, services.ErrKitMovement) || errors.Is(err, services.ErrInvalidKit)
+}
+
+func respondKitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isKitError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
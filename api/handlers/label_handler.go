@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type LabelHandler struct {
+	Service *services.LabelService
+}
+
+func NewLabelHandler(s *services.LabelService) *LabelHandler {
+	return &LabelHandler{Service: s}
+}
+
+func (h *LabelHandler) ListLayouts(c *gin.Context) {
+	layouts := make([]services.LabelLayout, 0, len(services.LabelLayouts))
+	for _, layout := range services.LabelLayouts {
+		layouts = append(layouts, layout)
+	}
+	sort.Slice(layouts, func(i, j int) bool { return layouts[i].Name < layouts[j].Name })
+
+	c.JSON(http.StatusOK, layouts)
+}
+
+func (h *LabelHandler) PrintPDF(c *gin.Context) {
+	var req dto.PrintLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	labels, err := h.Service.BuildLabels(accountID, labelRequests(req.Items), services.LabelSymbology(req.Symbology))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layout := req.Layout
+	if layout == "" {
+		layout = "a4-21"
+	}
+
+	pdf, err := h.Service.RenderPDF(labels, layout, req.Skip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=etiquettes-"+time.Now().Format("20060102-150405")+".pdf")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+func labelRequests(items []dto.LabelItemRequest) []services.LabelRequest {
+	requests := make([]services.LabelRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, services.LabelRequest{ArticleID: item.ArticleID, Quantity: item.Quantity})
+	}
+	return requests
+}
+
+// DownloadZPL returns the ZPL II document for the requested labels.
+func (h *LabelHandler) DownloadZPL(c *gin.Context) {
+	var req dto.ZPLLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	zpl, ok := h.renderZPL(c, &req)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=etiquettes-"+time.Now().Format("20060102-150405")+".zpl")
+	c.Data(http.StatusOK, "application/zpl; charset=utf-8", []byte(zpl))
+}
+
+// PrintZPL streams the labels to the shop's printer over raw TCP. Owners and admins
+// may target another printer of the local network with printer_address.
+func (h *LabelHandler) PrintZPL(c *gin.Context) {
+	var req dto.ZPLLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, ok := h.printerAddress(c, req.ShopID, req.PrinterAddress)
+	if !ok {
+		return
+	}
+
+	zpl, ok := h.renderZPL(c, &req)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.SendToPrinter(address, zpl, printerTimeout); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "labels sent to printer", "printer_address": address})
+}
+
+// TestPrinter sends a single test label so a shop can check its printer setup.
+func (h *LabelHandler) TestPrinter(c *gin.Context) {
+	var req struct {
+		ShopID         *uuid.UUID `json:"shop_id"`
+		PrinterAddress string     `json:"printer_address"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, ok := h.printerAddress(c, req.ShopID, req.PrinterAddress)
+	if !ok {
+		return
+	}
+
+	zpl, err := h.Service.RenderZPL([]services.Label{services.TestLabel()}, services.ZPLOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.SendToPrinter(address, zpl, printerTimeout); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test label sent", "printer_address": address})
+}
+
+const printerTimeout = 5 * time.Second
+
+func (h *LabelHandler) renderZPL(c *gin.Context, req *dto.ZPLLabelsRequest) (string, bool) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	labels, err := h.Service.BuildLabels(accountID, labelRequests(req.Items), services.LabelSymbology(req.Symbology))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	zpl, err := h.Service.RenderZPL(labels, services.ZPLOptions{WidthMM: req.WidthMM, HeightMM: req.HeightMM, DPI: req.DPI})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return zpl, true
+}
+
+// printerAddress resolves the target printer: the configured printer of the given
+// shop (the vendor's own shop for vendors), or an explicit address, which only owners
+// and admins may give and which must be a private LAN IP.
+func (h *LabelHandler) printerAddress(c *gin.Context, shopID *uuid.UUID, address string) (string, bool) {
+	if address != "" {
+		role := c.GetString("role")
+		if role != string(models.RoleOwner) && role != string(models.RoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only owners can print to a printer other than the shop's"})
+			return "", false
+		}
+		address, err := services.CheckLANPrinterAddress(address)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return "", false
+		}
+		return address, true
+	}
+
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err == nil {
+			shopID = &id
+		}
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "shop_id or printer_address is required"})
+		return "", false
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shop models.Shop
+	if err := h.Service.DB.Where("id = ? AND account_id = ?", *shopID, accountID).First(&shop).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shop not found"})
+		return "", false
+	}
+	if shop.PrinterAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no printer configured for this shop"})
+		return "", false
+	}
+	// Also checked here for addresses saved before UpdatePrinter enforced it
+	address, err := services.CheckLANPrinterAddress(shop.PrinterAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return address, true
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"stock_management/services"
+	"stock_management/storage"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MediaHandler struct {
+	Service *services.MediaService
+}
+
+func NewMediaHandler(s *services.MediaService) *MediaHandler {
+	return &MediaHandler{Service: s}
+}
+
+// UploadArticleImage expects a multipart form with the image in the "file" field.
+func (h *MediaHandler) UploadArticleImage(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	file, ok := h.formFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	article, err := h.Service.UploadArticleImage(c.Request.Context(), accountID, articleID, file)
+	if err != nil {
+		respondMediaError(c, err, "Article not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *MediaHandler) UploadBackgroundImage(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	file, ok := h.formFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	url, err := h.Service.UploadBackgroundImage(c.Request.Context(), accountID, file)
+	if err != nil {
+		respondMediaError(c, err, "Account not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"background_image": url})
+}
+
+// ServeMedia streams a stored file. Keys contain a random UUID and are never
+// rewritten, so responses can be cached indefinitely.
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	obj, err := h.Service.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer obj.Body.Close()
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, nil)
+}
+
+// multipartOverhead is the room left for the boundaries and part headers of the form.
+const multipartOverhead = 64 << 10
+
+// formFile opens the "file" part of the multipart form. The body is capped before it
+// is parsed, so an oversized upload is cut off instead of spooled to disk.
+func (h *MediaHandler) formFile(c *gin.Context) (multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.Service.MaxUploadBytes+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrImageTooLarge.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file: send the image in the \"file\" field of a multipart form"})
+		return nil, false
+	}
+	if header.Size > h.Service.MaxUploadBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrImageTooLarge.Error()})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return file, true
+}
+
+func respondMediaError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnsupportedImage):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceHandler struct {
+	Service *services.PriceService
+}
+
+func NewPriceHandler(s *services.PriceService) *PriceHandler {
+	return &PriceHandler{Service: s}
+}
+
+func (h *PriceHandler) CreatePriceList(c *gin.Context) {
+	var req dto.PriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	list := &models.PriceList{AccountID: accountID}
+	priceListFromRequest(list, &req)
+	if err := h.Service.CreatePriceList(list); err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// ListPriceLists returns the price lists, those of one shop with ?shop_id=.
+func (h *PriceHandler) ListPriceLists(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shopID *uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return
+		}
+		shopID = &id
+	}
+
+	lists, err := h.Service.GetPriceLists(accountID, shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+func (h *PriceHandler) GetPriceList(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	list, err := h.Service.GetPriceList(accountID, listID)
+	if err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *PriceHandler) UpdatePriceList(c *gin.Context) {
+	var req dto.PriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	list, err := h.Service.GetPriceList(accountID, listID)
+	if err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	priceListFromRequest(list, &req)
+	if err := h.Service.UpdatePriceList(list); err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *PriceHandler) DeletePriceList(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.DeletePriceList(accountID, listID); err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "price list deleted successfully"})
+}
+
+// SetPriceListItems adds or replaces article prices in the list.
+func (h *PriceHandler) SetPriceListItems(c *gin.Context) {
+	var req dto.PriceListItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	items := make([]models.PriceListItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, models.PriceListItem{ArticleID: item.ArticleID, Price: item.Price})
+	}
+	if err := h.Service.SetPriceListItems(accountID, listID, items); err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	list, err := h.Service.GetPriceList(accountID, listID)
+	if err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *PriceHandler) RemovePriceListItem(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+		return
+	}
+	articleID, err := uuid.Parse(c.Param("article_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.RemovePriceListItem(accountID, listID, articleID); err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "price removed successfully"})
+}
+
+// GetArticlePrice resolves the selling price of an article for ?shop_id=, ?price_list_id=
+// and ?at= (RFC 3339, now by default). Vendors always get the price of their shop.
+func (h *PriceHandler) GetArticlePrice(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shopID, priceListID *uuid.UUID
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return
+		}
+		shopID = &id
+	} else if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return
+		}
+		shopID = &id
+	}
+	if listIDStr := c.Query("price_list_id"); listIDStr != "" {
+		id, err := uuid.Parse(listIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price list id"})
+			return
+		}
+		priceListID = &id
+	}
+
+	at := time.Now()
+	if atStr := c.Query("at"); atStr != "" {
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected RFC 3339"})
+			return
+		}
+	}
+
+	price, err := h.Service.ResolvePrice(accountID, articleID, shopID, priceListID, at)
+	if err != nil {
+		respondPriceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+func priceListFromRequest(list *models.PriceList, req *dto.PriceListRequest) {
+	list.Name = req.Name
+	list.ShopID = req.ShopID
+	list.ValidFrom = req.ValidFrom
+	list.ValidTo = req.ValidTo
+	list.IsActive = req.IsActive == nil || *req.IsActive
+	list.Shop = nil
+}
+
+func respondPriceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPriceList):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPriceListNotEffective):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProductionHandler struct {
+	Service *services.ProductionService
+}
+
+func NewProductionHandler(s *services.ProductionService) *ProductionHandler {
+	return &ProductionHandler{Service: s}
+}
+
+func (h *ProductionHandler) CreateProductionOrder(c *gin.Context) {
+	var req dto.ProductionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	// Vendors can only produce in their own shop
+	if c.GetString("role") == string(models.RoleVendor) && c.GetString("shop_id") != req.ShopID.String() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "vendors can only produce in their own shop"})
+		return
+	}
+
+	order := &models.ProductionOrder{
+		AccountID: accountID,
+		ShopID:    req.ShopID,
+		Notes:     req.Notes,
+		ExtraCost: req.ExtraCost,
+		CreatedBy: userID,
+	}
+	for _, input := range req.Inputs {
+		order.Inputs = append(order.Inputs, models.ProductionInput{ArticleID: input.ArticleID, PlannedQty: input.PlannedQty})
+	}
+	for _, output := range req.Outputs {
+		order.Outputs = append(order.Outputs, models.ProductionOutput{
+			ArticleID:  output.ArticleID,
+			PlannedQty: output.PlannedQty,
+			CostShare:  output.CostShare,
+		})
+	}
+
+	if err := h.Service.CreateProductionOrder(order); err != nil {
+		respondProductionError(c, err)
+		return
+	}
+
+	created, err := h.Service.GetProductionOrder(accountID, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListProductionOrders returns the orders of the account, of their own shop for vendors.
+func (h *ProductionHandler) ListProductionOrders(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	filter := services.ProductionFilter{Status: models.ProductionStatus(c.Query("status"))}
+	if c.GetString("role") == string(models.RoleVendor) {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return
+		}
+		filter.ShopID = &id
+	} else if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return
+		}
+		filter.ShopID = &id
+	}
+
+	orders, err := h.Service.GetProductionOrders(accountID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *ProductionHandler) GetProductionOrder(c *gin.Context) {
+	order, ok := h.loadOrder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CompleteProductionOrder consumes the inputs and stocks the outputs of a draft order,
+// with the actual quantities given in the body (the plan when it is empty).
+func (h *ProductionHandler) CompleteProductionOrder(c *gin.Context) {
+	var req dto.CompleteProductionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, ok := h.loadOrder(c)
+	if !ok {
+		return
+	}
+
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	deviceID := req.DeviceID
+	if deviceID == "" {
+		deviceID = c.GetHeader("X-Device-ID")
+		if deviceID == "" {
+			deviceID = c.Request.UserAgent()
+		}
+	}
+
+	completed, err := h.Service.CompleteProductionOrder(order.AccountID, order.ID, userID,
+		productionActuals(req.Inputs), productionActuals(req.Outputs), deviceID)
+	if err != nil {
+		respondProductionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, completed)
+}
+
+func (h *ProductionHandler) CancelProductionOrder(c *gin.Context) {
+	order, ok := h.loadOrder(c)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.Service.CancelProductionOrder(order.AccountID, order.ID)
+	if err != nil {
+		respondProductionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// loadOrder fetches the order of the :id parameter, hiding the orders of other shops
+// from vendors. It writes the error response and returns false when it fails.
+func (h *ProductionHandler) loadOrder(c *gin.Context) (*models.ProductionOrder, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid production order id"})
+		return nil, false
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	order, err := h.Service.GetProductionOrder(accountID, orderID)
+	if err != nil {
+		respondProductionError(c, err)
+		return nil, false
+	}
+	if c.GetString("role") == string(models.RoleVendor) && c.GetString("shop_id") != order.ShopID.String() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Production order not found"})
+		return nil, false
+	}
+	return order, true
+}
+
+func productionActuals(lines []dto.ProductionActualRequest) []services.ProductionActual {
+	actuals := make([]services.ProductionActual, 0, len(lines))
+	for _, line := range lines {
+		actuals = append(actuals, services.ProductionActual{ArticleID: line.ArticleID, Qty: line.Qty, WasteQty: line.WasteQty})
+	}
+	return actuals
+}
+
+// respondProductionError maps production service errors to HTTP status codes.
+func respondProductionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Production order not found"})
+	case errors.Is(err, services.ErrProductionNotEditable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidProduction),
+		errors.Is(err, services.ErrNothingProduced),
+		errors.Is(err, services.ErrInsufficientStock),
+		isUnitError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/models"
+	"stock_management/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PurchaseHandler struct {
+	Service *services.PurchaseService
+}
+
+func NewPurchaseHandler(s *services.PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{Service: s}
+}
+
+func (h *PurchaseHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req dto.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	order := purchaseOrderFromRequest(&req)
+	order.AccountID = accountID
+	order.CreatedBy = userID
+
+	if err := h.Service.CreateDraftOrder(order); err != nil {
+		respondPurchaseError(c, err)
+		return
+	}
+
+	created, err := h.Service.GetPurchaseOrder(accountID, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *PurchaseHandler) ListPurchaseOrders(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	filter := services.PurchaseOrderFilter{
+		Status: models.OrderStatus(c.Query("status")),
+	}
+	if supplierIDStr := c.Query("supplier_id"); supplierIDStr != "" {
+		filter.SupplierID, _ = uuid.Parse(supplierIDStr)
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err := time.Parse("2006-01-02", fromStr); err == nil {
+			filter.From = &from
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err := time.Parse("2006-01-02", toStr); err == nil {
+			end := to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			filter.To = &end
+		}
+	}
+
+	orders, err := h.Service.GetPurchaseOrders(accountID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *PurchaseHandler) GetPurchaseOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	order, err := h.Service.GetPurchaseOrder(accountID, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *PurchaseHandler) UpdatePurchaseOrder(c *gin.Context) {
+	var req dto.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	order, err := h.Service.UpdateDraftOrder(accountID, orderID, purchaseOrderFromRequest(&req))
+	if err != nil {
+		respondPurchaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *PurchaseHandler) SendPurchaseOrder(c *gin.Context) {
+	h.changeStatus(c, h.Service.SendOrder)
+}
+
+func (h *PurchaseHandler) CancelPurchaseOrder(c *gin.Context) {
+	h.changeStatus(c, h.Service.CancelOrder)
+}
+
+func (h *PurchaseHandler) changeStatus(c *gin.Context, apply func(accountID, orderID uuid.UUID) (*models.PurchaseOrder, error)) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	order, err := apply(accountID, orderID)
+	if err != nil {
+		respondPurchaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *PurchaseHandler) ReceivePurchaseOrder(c *gin.Context) {
+	var req dto.ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	// Vendors can only receive goods into their own shop
+	if c.GetString("role") == string(models.RoleVendor) && c.GetString("shop_id") != req.ShopID.String() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "vendors can only receive goods into their own shop"})
+		return
+	}
+
+	deviceID := req.DeviceID
+	if deviceID == "" {
+		deviceID = c.GetHeader("X-Device-ID")
+		if deviceID == "" {
+			deviceID = c.Request.UserAgent()
+		}
+	}
+
+	lines := make([]services.ReceptionLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, services.ReceptionLine{ArticleID: line.ArticleID, Qty: line.Qty})
+	}
+
+	order, err := h.Service.ReceiveOrder(accountID, orderID, req.ShopID, userID, lines, req.AllowOverReceipt, deviceID)
+	if err != nil {
+		respondPurchaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GenerateReorder creates draft purchase orders for every article below its MinThreshold.
+func (h *PurchaseHandler) GenerateReorder(c *gin.Context) {
+	var req dto.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+	userIDStr := c.GetString("user_id")
+	userID, _ := uuid.Parse(userIDStr)
+
+	shopID := uuid.Nil
+	if req.ShopID != nil {
+		shopID = *req.ShopID
+	}
+
+	result, err := h.Service.GenerateReorderProposals(accountID, userID, shopID, req.Coverage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func purchaseOrderFromRequest(req *dto.PurchaseOrderRequest) *models.PurchaseOrder {
+	order := &models.PurchaseOrder{
+		SupplierID: req.SupplierID,
+		Notes:      req.Notes,
+	}
+	if req.OrderDate != nil {
+		order.OrderDate = *req.OrderDate
+	}
+	for _, item := range req.Items {
+		order.Items = append(order.Items, models.PurchaseOrderItem{
+			ArticleID: item.ArticleID,
+			Quantity:  item.Quantity,
+			Unit:      item.Unit,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return order
+}
+
+// respondPurchaseError maps purchasing service errors to HTTP status codes.
+func respondPurchaseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+	case errors.Is(err, services.ErrInvalidOrderTransition), errors.Is(err, services.ErrOrderNotEditable),
+		errors.Is(err, services.ErrOverReceipt):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPurchaseOrder), errors.Is(err, services.ErrKitMovement),
+		isUnitError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
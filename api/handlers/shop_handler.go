@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"stock_management/dto"
+	"stock_management/models"
 	"stock_management/services"
 
 	"github.com/gin-gonic/gin"
@@ -36,6 +37,45 @@ func (h *ShopHandler) CreateShop(c *gin.Context) {
 	c.JSON(http.StatusCreated, shop)
 }
 
+func (h *ShopHandler) UpdatePrinter(c *gin.Context) {
+	var req dto.PrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.GetString("role")
+	if role != string(models.RoleOwner) && role != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can change the printer of a shop"})
+		return
+	}
+
+	shopID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+		return
+	}
+
+	address := req.PrinterAddress
+	if address != "" {
+		address, err = services.CheckLANPrinterAddress(address)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.UpdatePrinter(accountID, shopID, address); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shop not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "printer updated successfully", "printer_address": address})
+}
+
 func (h *ShopHandler) ListShops(c *gin.Context) {
 	accountIDStr := c.GetString("account_id")
 	accountID, _ := uuid.Parse(accountIDStr)
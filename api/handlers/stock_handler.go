@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"stock_management/dto"
 	"stock_management/models"
 	"stock_management/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -41,12 +43,26 @@ func (h *StockHandler) RecordMovement(c *gin.Context) {
 		}
 	}
 
-	movement, err := h.Service.RecordMovement(
-		accountID, req.ShopID, req.ArticleID, userID,
-		moveType, req.Qty, req.Reason, deviceID,
-	)
+	var movement *models.StockMovement
+	var err error
+	if moveType == models.MovementOut {
+		movement, err = h.Service.RecordSale(accountID, req.ShopID, req.ArticleID, userID, req.Qty, req.Unit, req.PriceListID, req.Reason, deviceID)
+	} else {
+		movement, err = h.Service.RecordMovement(
+			accountID, req.ShopID, req.ArticleID, userID,
+			moveType, req.Qty, req.Unit, req.UnitCost, req.Reason, deviceID,
+		)
+	}
 
 	if err != nil {
+		if errors.Is(err, services.ErrPriceListNotEffective) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if isUnitError(err) || isKitError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -89,7 +105,7 @@ func (h *StockHandler) ListMovements(c *gin.Context) {
 	accountIDStr := c.GetString("account_id")
 	accountID, _ := uuid.Parse(accountIDStr)
 
-	movements, err := h.Service.GetMovements(accountID, shopID, articleID)
+	movements, err := h.Service.GetMovements(accountID, shopID, articleID, c.Query("reference"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -97,3 +113,87 @@ func (h *StockHandler) ListMovements(c *gin.Context) {
 
 	c.JSON(http.StatusOK, movements)
 }
+
+func (h *StockHandler) ListCostLayers(c *gin.Context) {
+	var shopID, articleID uuid.UUID
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		shopID, _ = uuid.Parse(shopIDStr)
+	}
+	if articleIDStr := c.Query("article_id"); articleIDStr != "" {
+		articleID, _ = uuid.Parse(articleIDStr)
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	layers, err := h.Service.GetCostLayers(accountID, shopID, articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, layers)
+}
+
+// GetValuation values stock at a given date (?date=YYYY-MM-DD, default now) using ?method=fifo|average.
+func (h *StockHandler) GetValuation(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	shopID := uuid.Nil
+	if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		shopID, _ = uuid.Parse(shopIDStr)
+	}
+
+	date := time.Now()
+	if dateStr := c.Query("date"); dateStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+		date = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	method := services.ValuationMethod(c.DefaultQuery("method", string(services.ValuationFIFO)))
+
+	report, err := h.Service.GetValuationReport(accountID, shopID, date, method)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportStockLevels downloads the stock of every shop (the vendor's only) as an .xlsx workbook.
+func (h *StockHandler) ExportStockLevels(c *gin.Context) {
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	var shopID *uuid.UUID
+	if c.GetString("role") == "vendor" {
+		id, err := uuid.Parse(c.GetString("shop_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "vendor account configuration error: no shop assigned or outdated token"})
+			return
+		}
+		shopID = &id
+	} else if shopIDStr := c.Query("shop_id"); shopIDStr != "" {
+		id, err := uuid.Parse(shopIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop id"})
+			return
+		}
+		shopID = &id
+	}
+
+	data, err := h.Service.ExportStockLevelsXLSX(accountID, shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=stock-"+time.Now().Format("20060102")+".xlsx")
+	c.Data(http.StatusOK, services.XLSXContentType, data)
+}
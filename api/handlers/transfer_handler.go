@@ -40,10 +40,14 @@ func (h *TransferHandler) InitiateTransfer(c *gin.Context) {
 
 	transfer, err := h.Service.InitiateTransfer(
 		accountID, req.FromShopID, req.ToShopID, req.ArticleID, userID,
-		req.Qty, req.Reason, deviceID,
+		req.Qty, req.Unit, req.Reason, deviceID,
 	)
 
 	if err != nil {
+		if isUnitError(err) || isKitError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
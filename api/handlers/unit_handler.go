@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stock_management/dto"
+	"stock_management/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListUnits returns the alternative units of measure of an article.
+func (h *ArticleHandler) ListUnits(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	units, err := h.Service.GetArticleUnits(accountID, articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, units)
+}
+
+// SetUnit adds a unit to the article, or changes the factor of an existing one.
+func (h *ArticleHandler) SetUnit(c *gin.Context) {
+	var req dto.ArticleUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	unit, err := h.Service.SetArticleUnit(accountID, articleID, req.Name, req.Factor)
+	if err != nil {
+		respondUnitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+func (h *ArticleHandler) DeleteUnit(c *gin.Context) {
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+	unitID, err := uuid.Parse(c.Param("unit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid unit id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	if err := h.Service.DeleteArticleUnit(accountID, articleID, unitID); err != nil {
+		respondUnitError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateUnitSettings sets the stock unit of the article, whether it is weighed,
+// and its default purchase and sales units.
+func (h *ArticleHandler) UpdateUnitSettings(c *gin.Context) {
+	var req dto.UnitSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	articleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid article id"})
+		return
+	}
+
+	accountIDStr := c.GetString("account_id")
+	accountID, _ := uuid.Parse(accountIDStr)
+
+	article, err := h.Service.UpdateUnitSettings(accountID, articleID, services.UnitSettings{
+		StockUnit:    req.StockUnit,
+		DecimalQty:   req.DecimalQty,
+		PurchaseUnit: req.PurchaseUnit,
+		SalesUnit:    req.SalesUnit,
+	})
+	if err != nil {
+		respondUnitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, article)
+}
+
+// isUnitError reports whether err comes from a quantity that cannot be converted
+// to the stock unit of its article.
+func isUnitError(err error) bool {
+	return errors.Is(err, services.ErrUnknownUnit) || errors.Is(err, services.ErrInvalidUnit) ||
+		errors.Is(err, services.ErrFractionalQty)
+}
+
+func respondUnitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isUnitError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"stock_management/config"
 	"stock_management/db"
 	"stock_management/routes"
 	"stock_management/services"
+	"stock_management/storage"
+	"time"
 )
 
 func main() {
@@ -18,8 +21,26 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Stockage des fichiers envoyés (images)
+	store, err := storage.New(storage.Config{
+		Driver:      appConfig.StorageDriver,
+		LocalPath:   appConfig.StorageLocalPath,
+		S3Endpoint:  appConfig.S3Endpoint,
+		S3AccessKey: appConfig.S3AccessKey,
+		S3SecretKey: appConfig.S3SecretKey,
+		S3Bucket:    appConfig.S3Bucket,
+		S3Region:    appConfig.S3Region,
+		S3UseSSL:    appConfig.S3UseSSL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
 	// Initialiser les services
-	servicesManager := services.InitServices(gormDB, appConfig.JWTSecret)
+	servicesManager := services.InitServices(gormDB, appConfig.JWTSecret, store, appConfig.MaxUploadMB<<20)
+
+	// Application des changements de prix programmés
+	go servicesManager.ArticleService.RunPriceScheduler(context.Background(), time.Minute)
 
 	// Configurer les routes
 	router := routes.SetupRoutes(servicesManager)
@@ -23,6 +23,12 @@ type Account struct {
 	Status                AccountStatus  `gorm:"default:'trial'" json:"status"`
 	PrimaryColor          string         `gorm:"default:'#4f46e5'" json:"primary_color"`
 	BackgroundImage       string         `json:"background_image"`
+	BarcodePrefix         string         `gorm:"default:'200'" json:"barcode_prefix"` // Prefix of internal EAN-13 codes (GS1 in-store range 20-29)
+	BarcodeSequence       int64          `gorm:"default:0" json:"-"`
+	CodePrefix            string         `gorm:"default:'ART'" json:"code_prefix"` // Generated codes: <prefix><separator><counter>[check digit]
+	CodeSeparator         string         `gorm:"default:'-'" json:"code_separator"`
+	CodeDigits            int            `gorm:"default:5" json:"code_digits"`
+	CodeCheckDigit        bool           `gorm:"default:false" json:"code_check_digit"`
 	SubscriptionExpiresAt *time.Time     `json:"subscription_expires_at"`
 	CreatedAt             time.Time      `json:"created_at"`
 	UpdatedAt             time.Time      `json:"updated_at"`
@@ -8,22 +8,49 @@ import (
 )
 
 type Article struct {
-	ID           uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	AccountID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"account_id"`
-	Code         string         `gorm:"not null;index" json:"code"`
-	Name         string         `gorm:"not null" json:"name"`
-	Description  string         `json:"description"`
-	CategoryID   *uuid.UUID     `gorm:"type:uuid;index" json:"category_id"`
-	BrandID      *uuid.UUID     `gorm:"type:uuid;index" json:"brand_id"`
-	MinThreshold int            `gorm:"default:0" json:"min_threshold"`
-	Price        float64        `gorm:"type:decimal(10,2);default:0" json:"price"`
-	TotalStock   int            `gorm:"->" json:"total_stock"`
-	ImageURL     string         `json:"image_url"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
-
-	Account Account `gorm:"foreignKey:AccountID" json:"-"`
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	Code         string     `gorm:"not null;index" json:"code"`
+	Name         string     `gorm:"not null" json:"name"`
+	Description  string     `json:"description"`
+	CategoryID   *uuid.UUID `gorm:"type:uuid;index" json:"category_id"`
+	BrandID      *uuid.UUID `gorm:"type:uuid;index" json:"brand_id"`
+	MinThreshold float64    `gorm:"type:decimal(14,3);default:0" json:"min_threshold"`
+	Price        float64    `gorm:"type:decimal(10,2);default:0" json:"price"`
+	CostPrice    float64    `gorm:"type:decimal(10,2);default:0" json:"cost_price"` // Default cost when a reception carries none
+	TotalStock   float64    `gorm:"->" json:"total_stock"`
+	ImageURL     string     `json:"image_url"`
+	ThumbnailURL string     `json:"thumbnail_url"`
+
+	// Variants: a child article of a parent product, identified by size and colour.
+	// Its Price follows the parent's unless HasPriceOverride is set.
+	ParentID         *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	Size             string     `json:"size"`
+	Color            string     `json:"color"`
+	HasPriceOverride bool       `gorm:"default:false" json:"has_price_override"`
+
+	// Units of measure: quantities are stored in StockUnit. Movements, transfers and
+	// orders may be entered in any of Units, converted with their Factor.
+	StockUnit    string        `gorm:"not null;default:'unit'" json:"stock_unit"`
+	DecimalQty   bool          `gorm:"default:false" json:"decimal_qty"` // Weighed goods: fractional stock quantities allowed
+	PurchaseUnit string        `json:"purchase_unit"`                    // Default unit of purchase order lines; empty for StockUnit
+	SalesUnit    string        `json:"sales_unit"`                       // Unit clients offer for sales; empty for StockUnit
+	Units        []ArticleUnit `gorm:"foreignKey:ArticleID" json:"units,omitempty"`
+
+	// Kits (gift packs, combo offers) hold no stock of their own: selling one takes
+	// its Components out of stock, and their stock determines its availability.
+	IsKit      bool           `gorm:"default:false" json:"is_kit"`
+	Components []KitComponent `gorm:"foreignKey:KitID" json:"components,omitempty"`
+
+	// Custom attributes, keyed by the AttributeDefinition keys of the account.
+	Attributes Attributes `gorm:"type:jsonb;not null;default:'{}';index:idx_articles_attributes,type:gin" json:"attributes"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Account  Account          `gorm:"foreignKey:AccountID" json:"-"`
+	Barcodes []ArticleBarcode `gorm:"foreignKey:ArticleID" json:"barcodes,omitempty"`
 }
 
 func (a *Article) BeforeCreate(tx *gorm.DB) (err error) {
@@ -33,14 +60,106 @@ func (a *Article) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-type Category struct {
+// ArticleBarcode is one of the barcodes printed on or scanned for an article.
+type ArticleBarcode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_article_barcodes_account_code" json:"account_id"`
+	ArticleID uuid.UUID `gorm:"type:uuid;not null;index" json:"article_id"`
+	Code      string    `gorm:"not null;uniqueIndex:idx_article_barcodes_account_code" json:"code"`
+	Type      string    `gorm:"not null" json:"type"` // ean13, ean8, upca, code128
+	IsPrimary bool      `gorm:"default:false" json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b *ArticleBarcode) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return
+}
+
+// ArticleUnit is an alternative unit of measure of an article, such as a carton of
+// 12 or a 25 kg bag: one Name equals Factor stock units.
+type ArticleUnit struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	AccountID uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
-	Name      string    `gorm:"not null" json:"name"`
+	ArticleID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_article_units_article_name" json:"article_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_article_units_article_name" json:"name"`
+	Factor    float64   `gorm:"type:decimal(14,6);not null" json:"factor"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (u *ArticleUnit) BeforeCreate(tx *gorm.DB) (err error) {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return
+}
+
+// KitComponent is a line of the bill of materials of a kit: Qty stock units of the
+// component article go into one kit.
+type KitComponent struct {
+	KitID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"kit_id"`
+	ComponentID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"component_id"`
+	AccountID   uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
+	Qty         float64   `gorm:"type:decimal(14,3);not null" json:"qty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Component *Article `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+}
+
+// CategoryNameIndex and BrandNameIndex are the unique indexes on (account_id, LOWER(name))
+// created by db.InitDatabase: tag indexes cannot hold expressions.
+const (
+	CategoryNameIndex = "idx_categories_account_lower_name"
+	BrandNameIndex    = "idx_brands_account_lower_name"
+)
+
+type Category struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	Name       string     `gorm:"not null" json:"name"` // Unique per account, case-insensitively (db.InitDatabase)
+	ParentID   *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	CodePrefix string     `json:"code_prefix"` // Overrides the account prefix for its articles and sub-categories
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Children []Category `gorm:"-" json:"children,omitempty"`
 }
 
+func (c *Category) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return
+}
+
+// CodeSequence is the last counter value used in the generated article codes of a prefix.
+type CodeSequence struct {
+	AccountID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Prefix    string    `gorm:"primaryKey"`
+	LastValue int64     `gorm:"not null;default:0"`
+}
+
+// CategorySubtreeSQL selects the id of a category (first parameter) and of all its descendants.
+const CategorySubtreeSQL = `WITH RECURSIVE category_subtree AS (
+	SELECT id FROM categories WHERE id = ?
+	UNION ALL
+	SELECT categories.id FROM categories JOIN category_subtree ON categories.parent_id = category_subtree.id
+) SELECT id FROM category_subtree`
+
 type Brand struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	AccountID uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
-	Name      string    `gorm:"not null" json:"name"`
+	Name      string    `gorm:"not null" json:"name"` // Unique per account, case-insensitively (db.InitDatabase)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (b *Brand) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return
 }
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttributeType string
+
+const (
+	AttributeText   AttributeType = "text"
+	AttributeNumber AttributeType = "number"
+	AttributeEnum   AttributeType = "enum"
+	AttributeDate   AttributeType = "date" // Stored as YYYY-MM-DD
+)
+
+// AttributeDefinition is a custom article field defined by an account, such as the
+// fabric of clothes or the voltage of appliances. Article.Attributes holds the values
+// by Key.
+type AttributeDefinition struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_attribute_definitions_account_key" json:"account_id"`
+	Key       string        `gorm:"not null;uniqueIndex:idx_attribute_definitions_account_key" json:"key"` // Lowercase identifier, used in filters and import columns
+	Label     string        `gorm:"not null" json:"label"`
+	Type      AttributeType `gorm:"not null" json:"type"`
+	Options   StringList    `gorm:"type:jsonb" json:"options,omitempty"` // Allowed values of an enum
+	Required  bool          `gorm:"default:false" json:"required"`
+	Position  int           `gorm:"default:0" json:"position"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func (d *AttributeDefinition) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
+
+// Attributes are the custom attribute values of an article, stored as a JSONB object:
+// strings for text, enum and date attributes, numbers for number attributes.
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+// StringList is a list of strings stored as a JSONB array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+func scanJSON(value interface{}, target interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, target)
+	case string:
+		return json.Unmarshal([]byte(v), target)
+	default:
+		return errors.New("unsupported JSON column value")
+	}
+}
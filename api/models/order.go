@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,44 @@ type Supplier struct {
 	Account Account `gorm:"foreignKey:AccountID" json:"-"`
 }
 
+func (s *Supplier) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// ArticleSupplier links an article to a supplier that can deliver it, with the
+// supplier's own reference and purchasing conditions. Prices and quantities are
+// per stock unit of the article.
+type ArticleSupplier struct {
+	ArticleID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"article_id"`
+	SupplierID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"supplier_id"`
+	AccountID         uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
+	SupplierRef       string    `json:"supplier_ref"`
+	LastPurchasePrice float64   `gorm:"type:decimal(10,2);default:0" json:"last_purchase_price"`
+	MinOrderQty       float64   `gorm:"type:decimal(14,3);default:0" json:"min_order_qty"`
+	PackSize          int       `gorm:"default:1" json:"pack_size"`
+	LeadTimeDays      int       `gorm:"default:0" json:"lead_time_days"`
+	IsPreferred       bool      `gorm:"default:false" json:"is_preferred"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	Article  Article  `gorm:"foreignKey:ArticleID" json:"-"`
+	Supplier Supplier `gorm:"foreignKey:SupplierID" json:"supplier"`
+}
+
+// RoundOrderQty raises qty to the minimum order quantity and to a whole number of packs.
+func (l *ArticleSupplier) RoundOrderQty(qty float64) float64 {
+	if qty < l.MinOrderQty {
+		qty = l.MinOrderQty
+	}
+	if l.PackSize > 1 {
+		qty = math.Ceil(qty/float64(l.PackSize)) * float64(l.PackSize)
+	}
+	return qty
+}
+
 type OrderStatus string
 
 const (
@@ -31,6 +70,23 @@ const (
 	OrderCancelled OrderStatus = "cancelled"
 )
 
+// orderTransitions lists, for each status, the statuses an order may move to.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderDraft:   {OrderSent, OrderCancelled},
+	OrderSent:    {OrderPartial, OrderReceived, OrderCancelled},
+	OrderPartial: {OrderPartial, OrderReceived, OrderCancelled},
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type PurchaseOrder struct {
 	ID          uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
 	AccountID   uuid.UUID   `gorm:"type:uuid;not null;index" json:"account_id"`
@@ -38,20 +94,50 @@ type PurchaseOrder struct {
 	Status      OrderStatus `gorm:"default:'draft'" json:"status"`
 	TotalAmount float64     `json:"total_amount"`
 	OrderDate   time.Time   `json:"order_date"`
+	Notes       string      `json:"notes"`
+	CreatedBy   uuid.UUID   `gorm:"type:uuid" json:"created_by"`
+	SentAt      *time.Time  `json:"sent_at,omitempty"`
+	CancelledAt *time.Time  `json:"cancelled_at,omitempty"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 
-	Account  Account  `gorm:"foreignKey:AccountID" json:"-"`
-	Supplier Supplier `gorm:"foreignKey:SupplierID" json:"-"`
+	Account  Account             `gorm:"foreignKey:AccountID" json:"-"`
+	Supplier Supplier            `gorm:"foreignKey:SupplierID" json:"supplier"`
+	Items    []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items"`
+}
+
+func (o *PurchaseOrder) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
+
+// ComputeTotal recalculates TotalAmount from the order lines.
+func (o *PurchaseOrder) ComputeTotal() {
+	total := 0.0
+	for _, item := range o.Items {
+		total += item.Quantity * item.UnitPrice
+	}
+	o.TotalAmount = total
 }
 
 type PurchaseOrderItem struct {
 	ID              uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	PurchaseOrderID uuid.UUID `gorm:"type:uuid;not null;index" json:"purchase_order_id"`
 	ArticleID       uuid.UUID `gorm:"type:uuid;not null;index" json:"article_id"`
-	Quantity        int       `gorm:"not null" json:"quantity"`
-	ReceivedQty     int       `gorm:"default:0" json:"received_qty"`
-	UnitPrice       float64   `json:"unit_price"`
+	Quantity        float64   `gorm:"type:decimal(14,3);not null" json:"quantity"` // In Unit
+	ReceivedQty     float64   `gorm:"type:decimal(14,3);default:0" json:"received_qty"`
+	Unit            string    `json:"unit"`                                            // Empty for the stock unit
+	UnitFactor      float64   `gorm:"type:decimal(14,6);default:1" json:"unit_factor"` // Stock units per Unit when ordered
+	UnitPrice       float64   `json:"unit_price"`                                      // Per Unit
+
+	Article Article `gorm:"foreignKey:ArticleID" json:"article"`
+}
 
-	Article Article `gorm:"foreignKey:ArticleID" json:"-"`
+func (i *PurchaseOrderItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
 }
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriceList overrides the selling price of some articles. A list bound to a shop
+// applies to every sale of that shop; the others (wholesale, promotions...) only
+// apply when chosen for a sale. ValidFrom and ValidTo (exclusive) bound the period
+// in which the list is effective; nil means unbounded.
+type PriceList struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	Name      string     `gorm:"not null" json:"name"`
+	ShopID    *uuid.UUID `gorm:"type:uuid;index" json:"shop_id"`
+	ValidFrom *time.Time `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+	IsActive  bool       `gorm:"not null" json:"is_active"` // Set explicitly: a default would make GORM skip false on create
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	Shop  *Shop           `gorm:"foreignKey:ShopID" json:"shop,omitempty"`
+	Items []PriceListItem `gorm:"foreignKey:PriceListID" json:"items,omitempty"`
+}
+
+func (p *PriceList) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// EffectiveAt reports whether the list applies at t.
+func (p *PriceList) EffectiveAt(t time.Time) bool {
+	return p.IsActive && (p.ValidFrom == nil || !t.Before(*p.ValidFrom)) && (p.ValidTo == nil || t.Before(*p.ValidTo))
+}
+
+type PriceListItem struct {
+	PriceListID uuid.UUID `gorm:"type:uuid;primaryKey" json:"price_list_id"`
+	ArticleID   uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"article_id"`
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Article *Article `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+}
+
+type PriceChangeSource string
+
+const (
+	PriceChangeManual    PriceChangeSource = "manual"
+	PriceChangeImport    PriceChangeSource = "import"
+	PriceChangeScheduled PriceChangeSource = "scheduled"
+	PriceChangeVariant   PriceChangeSource = "variant_sync" // Followed the price of the parent product
+)
+
+// PriceChange records an update of Article.Price.
+type PriceChange struct {
+	ID               uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"account_id"`
+	ArticleID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"article_id"`
+	OldPrice         float64           `gorm:"type:decimal(10,2)" json:"old_price"`
+	NewPrice         float64           `gorm:"type:decimal(10,2)" json:"new_price"`
+	UserID           *uuid.UUID        `gorm:"type:uuid" json:"user_id"` // Nil for changes applied by the system
+	Source           PriceChangeSource `gorm:"not null" json:"source"`
+	ScheduledPriceID *uuid.UUID        `gorm:"type:uuid" json:"scheduled_price_id"`
+	CreatedAt        time.Time         `gorm:"index" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (p *PriceChange) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+type ScheduledPriceStatus string
+
+const (
+	ScheduledPricePending   ScheduledPriceStatus = "pending"
+	ScheduledPriceApplied   ScheduledPriceStatus = "applied"
+	ScheduledPriceCancelled ScheduledPriceStatus = "cancelled"
+	ScheduledPriceFailed    ScheduledPriceStatus = "failed" // See Error
+)
+
+// ScheduledPrice is a price change applied by the scheduler at EffectiveAt.
+type ScheduledPrice struct {
+	ID          uuid.UUID            `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID   uuid.UUID            `gorm:"type:uuid;not null;index" json:"account_id"`
+	ArticleID   uuid.UUID            `gorm:"type:uuid;not null;index" json:"article_id"`
+	NewPrice    float64              `gorm:"type:decimal(10,2);not null" json:"new_price"`
+	EffectiveAt time.Time            `gorm:"not null;index:idx_scheduled_prices_due" json:"effective_at"`
+	Status      ScheduledPriceStatus `gorm:"not null;default:'pending';index:idx_scheduled_prices_due" json:"status"`
+	CreatedBy   uuid.UUID            `gorm:"type:uuid;not null" json:"created_by"`
+	AppliedAt   *time.Time           `json:"applied_at"`
+	Error       string               `json:"error,omitempty"` // Why the scheduler could not apply the change
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func (p *ScheduledPrice) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
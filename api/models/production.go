@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProductionStatus string
+
+const (
+	ProductionDraft     ProductionStatus = "draft"
+	ProductionCompleted ProductionStatus = "completed"
+	ProductionCancelled ProductionStatus = "cancelled"
+)
+
+// ProductionOrder turns input articles into output articles in a shop, such as bulk
+// rice repackaged into 1 kg bags. Completing it records the consumption of the inputs
+// and the production of the outputs as movements sharing its Reference.
+type ProductionOrder struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID   uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_production_orders_account_reference" json:"account_id"`
+	ShopID      uuid.UUID        `gorm:"type:uuid;not null;index" json:"shop_id"`
+	Reference   string           `gorm:"not null;uniqueIndex:idx_production_orders_account_reference" json:"reference"`
+	Status      ProductionStatus `gorm:"not null;default:'draft'" json:"status"`
+	Notes       string           `json:"notes"`
+	ExtraCost   float64          `gorm:"type:decimal(12,2);default:0" json:"extra_cost"` // Labour, packaging... added to the cost of the inputs
+	InputCost   float64          `gorm:"type:decimal(14,4);default:0" json:"input_cost"` // Cost of the consumed inputs, at completion
+	WasteCost   float64          `gorm:"type:decimal(14,4);default:0" json:"waste_cost"` // Part of InputCost lost as waste
+	CreatedBy   uuid.UUID        `gorm:"type:uuid" json:"created_by"`
+	CompletedBy *uuid.UUID       `gorm:"type:uuid" json:"completed_by,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CancelledAt *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	Shop    Shop               `gorm:"foreignKey:ShopID" json:"-"`
+	Inputs  []ProductionInput  `gorm:"foreignKey:ProductionOrderID" json:"inputs"`
+	Outputs []ProductionOutput `gorm:"foreignKey:ProductionOrderID" json:"outputs"`
+}
+
+func (o *ProductionOrder) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
+
+// ProductionInput is an article consumed by a production order. Quantities are in
+// the article's stock unit.
+type ProductionInput struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ProductionOrderID uuid.UUID `gorm:"type:uuid;not null;index" json:"production_order_id"`
+	ArticleID         uuid.UUID `gorm:"type:uuid;not null;index" json:"article_id"`
+	PlannedQty        float64   `gorm:"type:decimal(14,3);not null" json:"planned_qty"`
+	ConsumedQty       float64   `gorm:"type:decimal(14,3);default:0" json:"consumed_qty"` // Waste included
+	WasteQty          float64   `gorm:"type:decimal(14,3);default:0" json:"waste_qty"`    // Part of ConsumedQty lost (spillage, trimmings)
+	UnitCost          float64   `gorm:"type:decimal(12,4);default:0" json:"unit_cost"`
+
+	Article Article `gorm:"foreignKey:ArticleID" json:"article"`
+}
+
+func (i *ProductionInput) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
+
+// ProductionOutput is an article made by a production order. Quantities are in the
+// article's stock unit.
+type ProductionOutput struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ProductionOrderID uuid.UUID `gorm:"type:uuid;not null;index" json:"production_order_id"`
+	ArticleID         uuid.UUID `gorm:"type:uuid;not null;index" json:"article_id"`
+	PlannedQty        float64   `gorm:"type:decimal(14,3);not null" json:"planned_qty"`
+	ProducedQty       float64   `gorm:"type:decimal(14,3);default:0" json:"produced_qty"`
+	Yield             float64   `gorm:"type:decimal(8,4);default:0" json:"yield"`      // ProducedQty / PlannedQty
+	CostShare         float64   `gorm:"type:decimal(8,4);default:0" json:"cost_share"` // Weight in the cost allocation; by produced quantity when all are 0
+	UnitCost          float64   `gorm:"type:decimal(12,4);default:0" json:"unit_cost"`
+
+	Article Article `gorm:"foreignKey:ArticleID" json:"article"`
+}
+
+func (o *ProductionOutput) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
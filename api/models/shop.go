@@ -8,13 +8,15 @@ import (
 )
 
 type Shop struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	AccountID uuid.UUID      `gorm:"type:uuid;not null;index" json:"account_id"`
-	Name      string         `gorm:"not null" json:"name"`
-	Location  string         `json:"location"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Location  string    `json:"location"`
+	// Address (host[:port]) of the shop's ZPL thermal printer, port 9100 by default
+	PrinterAddress string         `json:"printer_address"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Account Account `gorm:"foreignKey:AccountID" json:"-"`
 }
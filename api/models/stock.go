@@ -4,12 +4,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type StockLevel struct {
 	ArticleID uuid.UUID `gorm:"type:uuid;primaryKey" json:"article_id"`
 	ShopID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"shop_id"`
-	Quantity  int       `gorm:"default:0" json:"quantity"`
+	Quantity  float64   `gorm:"type:decimal(14,3);default:0" json:"quantity"` // In the article's stock unit
+	AvgCost   float64   `gorm:"type:decimal(12,4);default:0" json:"avg_cost"` // Weighted-average unit cost
 	UpdatedAt time.Time `json:"updated_at"`
 
 	Article Article `gorm:"foreignKey:ArticleID"`
@@ -26,21 +28,49 @@ const (
 )
 
 type StockMovement struct {
-	ID        uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
-	AccountID uuid.UUID    `gorm:"type:uuid;not null;index" json:"account_id"`
-	ShopID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"shop_id"`
-	ArticleID uuid.UUID    `gorm:"type:uuid;not null;index" json:"article_id"`
-	UserID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
-	Type      MovementType `gorm:"not null" json:"type"`
-	Qty       int          `gorm:"not null" json:"qty"`
-	OldValue  int          `json:"old_value"`
-	NewValue  int          `json:"new_value"`
-	Reason    string       `json:"reason"`
-	DeviceID  string       `json:"device_id"`
-	CreatedAt time.Time    `json:"created_at"`
+	ID            uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"account_id"`
+	ShopID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"shop_id"`
+	ArticleID     uuid.UUID    `gorm:"type:uuid;not null;index" json:"article_id"`
+	UserID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type          MovementType `gorm:"not null" json:"type"`
+	Qty           float64      `gorm:"type:decimal(14,3);not null" json:"qty"` // In the article's stock unit
+	OldValue      float64      `gorm:"type:decimal(14,3)" json:"old_value"`
+	NewValue      float64      `gorm:"type:decimal(14,3)" json:"new_value"`
+	UnitCost      float64      `gorm:"type:decimal(12,4);default:0" json:"unit_cost"`
+	UnitPrice     *float64     `gorm:"type:decimal(10,2)" json:"unit_price"` // Selling price of a sale; nil falls back to Article.Price
+	Unit          string       `json:"unit"`                                 // Unit the quantity was entered in; empty for the stock unit
+	UnitQty       float64      `gorm:"type:decimal(14,3)" json:"unit_qty"`   // Quantity as entered, in Unit
+	Reason        string       `json:"reason"`
+	DeviceID      string       `json:"device_id"`
+	PriceListID   *uuid.UUID   `gorm:"type:uuid" json:"price_list_id"`                   // List the sale price came from
+	KitMovementID *uuid.UUID   `gorm:"type:uuid;index" json:"kit_movement_id,omitempty"` // Sale of the kit this component left stock for
+	Reference     string       `gorm:"index" json:"reference,omitempty"`                 // Shared by the movements of a production order
+	CreatedAt     time.Time    `json:"created_at"`
 
 	Account Account `gorm:"foreignKey:AccountID" json:"-"`
 	Shop    Shop    `gorm:"foreignKey:ShopID" json:"-"`
 	Article Article `gorm:"foreignKey:ArticleID" json:"-"`
 	User    User    `gorm:"foreignKey:UserID" json:"-"`
 }
+
+// CostLayer is a lot of stock received at a given unit cost. Inbound movements
+// open a layer and outbound movements consume the oldest layers first (FIFO).
+type CostLayer struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID    uuid.UUID `gorm:"type:uuid;not null;index" json:"account_id"`
+	ShopID       uuid.UUID `gorm:"type:uuid;not null;index:idx_cost_layers_stock" json:"shop_id"`
+	ArticleID    uuid.UUID `gorm:"type:uuid;not null;index:idx_cost_layers_stock" json:"article_id"`
+	MovementID   uuid.UUID `gorm:"type:uuid;not null;index" json:"movement_id"`
+	UnitCost     float64   `gorm:"type:decimal(12,4);default:0" json:"unit_cost"`
+	InitialQty   float64   `gorm:"type:decimal(14,3);not null" json:"initial_qty"`
+	RemainingQty float64   `gorm:"type:decimal(14,3);not null" json:"remaining_qty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (l *CostLayer) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return
+}
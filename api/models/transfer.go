@@ -21,7 +21,8 @@ type StockTransfer struct {
 	FromShopID uuid.UUID      `gorm:"type:uuid;not null;index" json:"from_shop_id"`
 	ToShopID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"to_shop_id"`
 	ArticleID  uuid.UUID      `gorm:"type:uuid;not null;index" json:"article_id"`
-	Qty        int            `gorm:"not null" json:"qty"`
+	Qty        float64        `gorm:"type:decimal(14,3);not null" json:"qty"`        // In the article's stock unit
+	UnitCost   float64        `gorm:"type:decimal(12,4);default:0" json:"unit_cost"` // Source shop cost at dispatch
 	Status     TransferStatus `gorm:"not null;default:'pending'" json:"status"`
 
 	InitiatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"initiated_by"`
@@ -14,9 +14,16 @@ func SetupRoutes(sm *services.ServicesManager) *gin.Engine {
 	// Initialize Handlers
 	authHandler := handlers.NewAuthHandler(sm.AccountService, sm.WhatsAppService, sm.JWTSecret)
 	articleHandler := handlers.NewArticleHandler(sm.ArticleService)
+	catalogHandler := handlers.NewCatalogHandler(sm.CatalogService)
+	labelHandler := handlers.NewLabelHandler(sm.LabelService)
+	mediaHandler := handlers.NewMediaHandler(sm.MediaService)
 	stockHandler := handlers.NewStockHandler(sm.StockService)
 	subscriptionHandler := handlers.NewSubscriptionHandler(sm.SubscriptionService, sm.AccountService)
 	shopHandler := handlers.NewShopHandler(sm.ShopService)
+	supplierHandler := handlers.NewSupplierHandler(sm.SupplierService)
+	purchaseHandler := handlers.NewPurchaseHandler(sm.PurchaseService)
+	priceHandler := handlers.NewPriceHandler(sm.PriceService)
+	productionHandler := handlers.NewProductionHandler(sm.ProductionService)
 	transferHandler := handlers.NewTransferHandler(sm.StockService)
 	dashboardHandler := handlers.NewDashboardHandler(sm.StockService)
 
@@ -35,6 +42,9 @@ func SetupRoutes(sm *services.ServicesManager) *gin.Engine {
 			auth.POST("/verify", authHandler.VerifyPhone)
 		}
 
+		// Uploaded images (public: referenced from <img> tags)
+		api.GET("/media/*key", mediaHandler.ServeMedia)
+
 		// Webhooks (Public)
 		api.POST("/subscription/webhook/paydunya", subscriptionHandler.HandlePayDunyaWebhook)
 
@@ -46,6 +56,31 @@ func SetupRoutes(sm *services.ServicesManager) *gin.Engine {
 			// Shops
 			protected.POST("/shops", shopHandler.CreateShop)
 			protected.GET("/shops", shopHandler.ListShops)
+			protected.PUT("/shops/:id/printer", shopHandler.UpdatePrinter)
+
+			// Suppliers
+			protected.POST("/suppliers", supplierHandler.CreateSupplier)
+			protected.GET("/suppliers", supplierHandler.ListSuppliers)
+			protected.PUT("/suppliers/:id", supplierHandler.UpdateSupplier)
+			protected.DELETE("/suppliers/:id", supplierHandler.DeleteSupplier)
+			protected.POST("/suppliers/:id/restore", supplierHandler.RestoreSupplier)
+
+			// Purchase Orders
+			protected.POST("/purchase-orders", purchaseHandler.CreatePurchaseOrder)
+			protected.GET("/purchase-orders", purchaseHandler.ListPurchaseOrders)
+			protected.POST("/purchase-orders/reorder", purchaseHandler.GenerateReorder)
+			protected.GET("/purchase-orders/:id", purchaseHandler.GetPurchaseOrder)
+			protected.PUT("/purchase-orders/:id", purchaseHandler.UpdatePurchaseOrder)
+			protected.POST("/purchase-orders/:id/send", purchaseHandler.SendPurchaseOrder)
+			protected.POST("/purchase-orders/:id/cancel", purchaseHandler.CancelPurchaseOrder)
+			protected.POST("/purchase-orders/:id/receive", purchaseHandler.ReceivePurchaseOrder)
+
+			// Production Orders
+			protected.POST("/production-orders", productionHandler.CreateProductionOrder)
+			protected.GET("/production-orders", productionHandler.ListProductionOrders)
+			protected.GET("/production-orders/:id", productionHandler.GetProductionOrder)
+			protected.POST("/production-orders/:id/complete", productionHandler.CompleteProductionOrder)
+			protected.POST("/production-orders/:id/cancel", productionHandler.CancelProductionOrder)
 
 			// Users
 			protected.POST("/users/invite", authHandler.InviteUser)
@@ -60,12 +95,71 @@ func SetupRoutes(sm *services.ServicesManager) *gin.Engine {
 			protected.PUT("/auth/profile", authHandler.UpdateProfile)
 			protected.POST("/auth/change-password", authHandler.ChangePassword)
 			protected.PUT("/auth/theme", authHandler.UpdateTheme)
+			protected.POST("/auth/background-image", mediaHandler.UploadBackgroundImage)
+			protected.PUT("/auth/barcode-prefix", authHandler.UpdateBarcodePrefix)
+			protected.PUT("/auth/code-scheme", authHandler.UpdateCodeScheme)
 
 			// Articles
 			protected.POST("/articles", articleHandler.CreateArticle)
 			protected.GET("/articles", articleHandler.ListArticles)
+			protected.GET("/articles/by-barcode/:code", articleHandler.GetByBarcode)
+			protected.POST("/articles/bulk", articleHandler.BulkUpdateArticles)
 			protected.PUT("/articles/:id", articleHandler.UpdateArticle)
+			protected.POST("/articles/:id/image", mediaHandler.UploadArticleImage)
+			protected.GET("/articles/:id/barcodes", articleHandler.ListBarcodes)
+			protected.POST("/articles/:id/barcodes", articleHandler.AddBarcode)
+			protected.DELETE("/articles/:id/barcodes/:barcode_id", articleHandler.RemoveBarcode)
+			protected.GET("/articles/:id/units", articleHandler.ListUnits)
+			protected.POST("/articles/:id/units", articleHandler.SetUnit)
+			protected.DELETE("/articles/:id/units/:unit_id", articleHandler.DeleteUnit)
+			protected.PUT("/articles/:id/unit-settings", articleHandler.UpdateUnitSettings)
+			protected.PUT("/articles/:id/components", articleHandler.SetKitComponents)
+			protected.GET("/articles/:id/availability", articleHandler.GetKitAvailability)
+			protected.POST("/articles/:id/variants", articleHandler.CreateVariant)
+			protected.POST("/articles/:id/variants/generate", articleHandler.GenerateVariants)
 			protected.POST("/articles/import", articleHandler.ImportArticles)
+			protected.GET("/articles/export", articleHandler.ExportArticles)
+			protected.GET("/articles/:id/price", priceHandler.GetArticlePrice)
+			protected.GET("/articles/:id/price-history", articleHandler.GetPriceHistory)
+			protected.GET("/articles/:id/scheduled-prices", articleHandler.ListScheduledPrices)
+			protected.POST("/articles/:id/scheduled-prices", articleHandler.SchedulePrice)
+			protected.GET("/scheduled-prices", articleHandler.ListScheduledPrices)
+			protected.DELETE("/scheduled-prices/:id", articleHandler.CancelScheduledPrice)
+			protected.GET("/articles/:id/suppliers", supplierHandler.ListArticleSuppliers)
+			protected.PUT("/articles/:id/suppliers/:supplier_id", supplierHandler.SaveArticleSupplier)
+			protected.DELETE("/articles/:id/suppliers/:supplier_id", supplierHandler.RemoveArticleSupplier)
+
+			// Price Lists
+			protected.POST("/price-lists", priceHandler.CreatePriceList)
+			protected.GET("/price-lists", priceHandler.ListPriceLists)
+			protected.GET("/price-lists/:id", priceHandler.GetPriceList)
+			protected.PUT("/price-lists/:id", priceHandler.UpdatePriceList)
+			protected.DELETE("/price-lists/:id", priceHandler.DeletePriceList)
+			protected.PUT("/price-lists/:id/items", priceHandler.SetPriceListItems)
+			protected.DELETE("/price-lists/:id/items/:article_id", priceHandler.RemovePriceListItem)
+
+			// Labels
+			protected.GET("/labels/layouts", labelHandler.ListLayouts)
+			protected.POST("/labels/pdf", labelHandler.PrintPDF)
+			protected.POST("/labels/zpl", labelHandler.DownloadZPL)
+			protected.POST("/labels/zpl/print", labelHandler.PrintZPL)
+			protected.POST("/labels/zpl/test", labelHandler.TestPrinter)
+
+			// Custom article attributes
+			protected.GET("/attributes", articleHandler.ListAttributes)
+			protected.POST("/attributes", articleHandler.CreateAttribute)
+			protected.PUT("/attributes/:id", articleHandler.UpdateAttribute)
+			protected.DELETE("/attributes/:id", articleHandler.DeleteAttribute)
+
+			// Categories & Brands
+			protected.POST("/categories", catalogHandler.CreateCategory)
+			protected.GET("/categories", catalogHandler.ListCategories)
+			protected.PUT("/categories/:id", catalogHandler.UpdateCategory)
+			protected.DELETE("/categories/:id", catalogHandler.DeleteCategory)
+			protected.POST("/brands", catalogHandler.CreateBrand)
+			protected.GET("/brands", catalogHandler.ListBrands)
+			protected.PUT("/brands/:id", catalogHandler.UpdateBrand)
+			protected.DELETE("/brands/:id", catalogHandler.DeleteBrand)
 
 			// Dashboard
 			protected.GET("/dashboard/stats", dashboardHandler.GetStats)
@@ -75,6 +169,9 @@ func SetupRoutes(sm *services.ServicesManager) *gin.Engine {
 			protected.POST("/stocks/movement", stockHandler.RecordMovement)
 			protected.GET("/stocks/levels", stockHandler.ListStockLevels)
 			protected.GET("/stocks/movements", stockHandler.ListMovements)
+			protected.GET("/stocks/cost-layers", stockHandler.ListCostLayers)
+			protected.GET("/stocks/valuation", stockHandler.GetValuation)
+			protected.GET("/stocks/export", stockHandler.ExportStockLevels)
 
 			// Transfers
 			protected.POST("/transfers", transferHandler.InitiateTransfer)
@@ -146,6 +146,25 @@ func (s *AccountService) DeleteUser(accountID, userID uuid.UUID) error {
 	return s.DB.Where("id = ? AND account_id = ?", userID, accountID).Delete(&models.User{}).Error
 }
 
+// UpdateBarcodePrefix sets the prefix of the internal EAN-13 codes generated for the account.
+func (s *AccountService) UpdateBarcodePrefix(accountID uuid.UUID, prefix string) error {
+	return s.DB.Model(&models.Account{}).Where("id = ?", accountID).Update("barcode_prefix", prefix).Error
+}
+
+// UpdateCodeScheme sets how the codes of new articles are generated; existing codes are kept.
+func (s *AccountService) UpdateCodeScheme(accountID uuid.UUID, prefix, separator string, digits int, checkDigit bool) error {
+	prefix, err := NormalizeCodePrefix(prefix)
+	if err != nil {
+		return err
+	}
+	return s.DB.Model(&models.Account{}).Where("id = ?", accountID).Updates(map[string]interface{}{
+		"code_prefix":      prefix,
+		"code_separator":   separator,
+		"code_digits":      digits,
+		"code_check_digit": checkDigit,
+	}).Error
+}
+
 func (s *AccountService) UpdateAccountTheme(accountID uuid.UUID, primaryColor, backgroundImage string) error {
 	return s.DB.Model(&models.Account{}).Where("id = ?", accountID).Updates(map[string]interface{}{
 		"primary_color":    primaryColor,
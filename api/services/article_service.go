@@ -1,15 +1,18 @@
 package services
 
 import (
-	"encoding/csv"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"math/rand"
 	"stock_management/models"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ArticleService struct {
@@ -20,15 +23,26 @@ func NewArticleService(db *gorm.DB) *ArticleService {
 	return &ArticleService{DB: db}
 }
 
-func (s *ArticleService) CreateArticle(article *models.Article, initialStock int, shopID *uuid.UUID, userID uuid.UUID) error {
+func (s *ArticleService) CreateArticle(article *models.Article, initialStock float64, shopID *uuid.UUID, userID uuid.UUID) error {
+	if article.StockUnit == "" {
+		article.StockUnit = DefaultStockUnit
+	}
 	return s.DB.Transaction(func(tx *gorm.DB) error {
 		if article.Code == "" {
-			code, err := s.GenerateCode(article.AccountID, "")
+			code, err := NewArticleService(tx).GenerateCode(article.AccountID, article.CategoryID)
 			if err != nil {
 				return err
 			}
 			article.Code = code
 		}
+		if err := prepareBarcodes(tx, article); err != nil {
+			return err
+		}
+		attributes, err := validateAttributes(tx, article.AccountID, article.Attributes, nil)
+		if err != nil {
+			return err
+		}
+		article.Attributes = attributes
 		if err := tx.Create(article).Error; err != nil {
 			return err
 		}
@@ -36,7 +50,7 @@ func (s *ArticleService) CreateArticle(article *models.Article, initialStock int
 		// Add Initial Stock if provided and shop is specified
 		if initialStock > 0 && shopID != nil && *shopID != uuid.Nil {
 			stockService := NewStockService(tx)
-			_, err := stockService.RecordMovement(article.AccountID, *shopID, article.ID, userID, models.MovementIn, initialStock, "Initial Stock", "system")
+			_, err := stockService.RecordMovement(article.AccountID, *shopID, article.ID, userID, models.MovementIn, initialStock, "", article.CostPrice, "Initial Stock", "system")
 			if err != nil {
 				return err
 			}
@@ -45,103 +59,234 @@ func (s *ArticleService) CreateArticle(article *models.Article, initialStock int
 	})
 }
 
-func (s *ArticleService) GenerateCode(accountID uuid.UUID, category string) (string, error) {
-	// Generate a unique Code: CAT-XXXXX or ART-XXXXX
-	prefix := "ART"
-	if category != "" {
-		prefix = strings.ToUpper(category[:3])
-	}
+// ArticleFilter narrows, sorts and paginates the articles returned by ListArticles.
+type ArticleFilter struct {
+	ShopID     *uuid.UUID // Only articles stocked in the shop; TotalStock is the shop's stock
+	CategoryID *uuid.UUID // Includes the sub-categories
+	BrandID    *uuid.UUID
+	Search     string // Every word must appear in the name, code or description, accents ignored
+	Stock      string // "low" (below min_threshold) or "out" (none left)
+	Attributes []AttributeFilter
+	Sort       string // name (default), code, price, stock or created_at
+	Desc       bool
 
-	for i := 0; i < 5; i++ { // Try 5 times to find a unique Code
-		randomPart := fmt.Sprintf("%05d", rand.Intn(100000))
-		code := fmt.Sprintf("%s-%s", prefix, randomPart)
+	// Page numbers start at 1. Cursor, when set, takes precedence over Page:
+	// it is the NextCursor of the previous page. PageSize 0 returns everything.
+	Page     int
+	PageSize int
+	Cursor   string
+}
 
-		var count int64
-		s.DB.Model(&models.Article{}).Where("account_id = ? AND code = ?", accountID, code).Count(&count)
-		if count == 0 {
-			return code, nil
-		}
-	}
+type ArticlePage struct {
+	Items      []models.Article `json:"items"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page,omitempty"`
+	PageSize   int              `json:"page_size"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+const MaxArticlePageSize = 200
+
+// ErrInvalidArticleFilter is returned for an unknown sort or stock filter, or a malformed cursor.
+var ErrInvalidArticleFilter = errors.New("invalid article filter")
+
+// articleSortColumns maps the accepted sort keys to their SQL expression;
+// "stock" is resolved by ListArticles as it depends on the shop.
+var articleSortColumns = map[string]string{
+	"name":       "articles.name",
+	"code":       "articles.code",
+	"price":      "articles.price",
+	"created_at": "articles.created_at",
+	"stock":      "",
+}
 
-	return "", fmt.Errorf("could not generate a unique Code after several attempts")
+// articleCursor is the position after the last article of a page (keyset pagination).
+type articleCursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
 }
 
-func (s *ArticleService) GetArticlesByAccount(accountID uuid.UUID, shopID *uuid.UUID) ([]models.Article, error) {
-	var articles []models.Article
+// ListArticles returns one page of the account's articles.
+func (s *ArticleService) ListArticles(accountID uuid.UUID, filter ArticleFilter) (*ArticlePage, error) {
+	// Kits have the stock their components can make
+	stockSQL := "(CASE WHEN articles.is_kit THEN (SELECT COALESCE(SUM(" + kitShopAvailabilitySQL("shops.id") + "), 0) FROM shops WHERE shops.account_id = articles.account_id AND shops.deleted_at IS NULL)" +
+		" ELSE (SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE stock_levels.article_id = articles.id) END)"
+	var stockArgs []interface{}
+	if filter.ShopID != nil {
+		stockSQL = "(CASE WHEN articles.is_kit THEN " + kitShopAvailabilitySQL("?") +
+			" ELSE (SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE stock_levels.article_id = articles.id AND stock_levels.shop_id = ?) END)"
+		stockArgs = []interface{}{*filter.ShopID, *filter.ShopID}
+	}
+
+	query := s.DB.Model(&models.Article{}).Where("articles.account_id = ?", accountID)
+	if filter.ShopID != nil {
+		query = query.Where("(articles.is_kit OR EXISTS (SELECT 1 FROM stock_levels WHERE stock_levels.article_id = articles.id AND stock_levels.shop_id = ?))", *filter.ShopID)
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("articles.category_id IN ("+models.CategorySubtreeSQL+")", *filter.CategoryID)
+	}
+	if filter.BrandID != nil {
+		query = query.Where("articles.brand_id = ?", *filter.BrandID)
+	}
+	for _, word := range strings.Fields(filter.Search) {
+		pattern := "%" + escapeLike(word) + "%"
+		query = query.Where("(unaccent(articles.name) ILIKE unaccent(?) OR unaccent(articles.code) ILIKE unaccent(?) OR unaccent(articles.description) ILIKE unaccent(?))",
+			pattern, pattern, pattern)
+	}
+	query, err := applyAttributeFilters(s.DB, query, accountID, filter.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	switch filter.Stock {
+	case "low":
+		query = query.Where(stockSQL+" < articles.min_threshold", stockArgs...)
+	case "out":
+		query = query.Where(stockSQL+" <= 0", stockArgs...)
+	case "":
+	default:
+		return nil, fmt.Errorf("%w: unknown stock filter %q", ErrInvalidArticleFilter, filter.Stock)
+	}
 
-	selectQuery := "articles.*"
-	stockSubQuery := "(SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE stock_levels.article_id = articles.id"
+	page := &ArticlePage{Items: []models.Article{}, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
 
-	if shopID != nil {
-		stockSubQuery += fmt.Sprintf(" AND stock_levels.shop_id = '%s'", shopID.String())
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+	sortSQL, ok := articleSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidArticleFilter, filter.Sort)
+	}
+	sortArgs := []interface{}(nil)
+	if filter.Sort == "stock" {
+		sortSQL, sortArgs = stockSQL, stockArgs
+	}
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
 	}
 
-	stockSubQuery += ") as total_stock"
+	query = query.Select("articles.*, "+stockSQL+" AS total_stock", stockArgs...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  sortSQL + " " + direction + ", articles.id " + direction,
+			Vars: sortArgs,
+		}})
 
-	query := s.DB.Model(&models.Article{}).
-		Select(selectQuery+", "+stockSubQuery).
-		Where("articles.account_id = ?", accountID)
+	if filter.PageSize > 0 {
+		if filter.PageSize > MaxArticlePageSize {
+			filter.PageSize = MaxArticlePageSize
+			page.PageSize = MaxArticlePageSize
+		}
+		if filter.Cursor != "" {
+			value, id, err := decodeArticleCursor(filter.Cursor, filter.Sort)
+			if err != nil {
+				return nil, err
+			}
+			args := append(append([]interface{}{}, sortArgs...), value, id)
+			query = query.Where("("+sortSQL+", articles.id) "+compare+" (?, ?)", args...)
+		} else {
+			if filter.Page < 1 {
+				filter.Page = 1
+			}
+			page.Page = filter.Page
+			query = query.Offset((filter.Page - 1) * filter.PageSize)
+		}
+		query = query.Limit(filter.PageSize)
+	}
+
+	if err := query.Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
 
-	if shopID != nil {
-		query = query.Joins("JOIN stock_levels ON stock_levels.article_id = articles.id").
-			Where("stock_levels.shop_id = ?", shopID)
+	if filter.PageSize > 0 && len(page.Items) == filter.PageSize {
+		page.NextCursor = encodeArticleCursor(&page.Items[len(page.Items)-1], filter.Sort)
 	}
+	return page, nil
+}
 
-	err := query.Find(&articles).Error
-	return articles, err
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
-func (s *ArticleService) UpdateArticle(article *models.Article) error {
-	return s.DB.Save(article).Error
+func encodeArticleCursor(a *models.Article, sort string) string {
+	c := articleCursor{ID: a.ID}
+	switch sort {
+	case "code":
+		c.Value = a.Code
+	case "price":
+		c.Value = strconv.FormatFloat(a.Price, 'f', -1, 64)
+	case "stock":
+		c.Value = strconv.FormatFloat(a.TotalStock, 'f', -1, 64)
+	case "created_at":
+		c.Value = a.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = a.Name
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
 }
 
-func (s *ArticleService) ImportArticlesFromCSV(accountID uuid.UUID, reader io.Reader) (int, error) {
-	csvReader := csv.NewReader(reader)
-	csvReader.TrimLeadingSpace = true
+var errInvalidCursor = fmt.Errorf("%w: malformed cursor", ErrInvalidArticleFilter)
 
-	// Read header
-	headers, err := csvReader.Read()
+// decodeArticleCursor returns the position of the cursor, its value typed for the sort column.
+func decodeArticleCursor(cursor, sort string) (interface{}, uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return 0, err
+		return nil, uuid.Nil, errInvalidCursor
+	}
+	var c articleCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, uuid.Nil, errInvalidCursor
 	}
 
-	// Simple header mapping (Code, Name, Description, MinThreshold)
-	headerMap := make(map[string]int)
-	for i, h := range headers {
-		headerMap[strings.ToLower(h)] = i
+	var value interface{} = c.Value
+	switch sort {
+	case "price":
+		value, err = strconv.ParseFloat(c.Value, 64)
+	case "stock":
+		value, err = strconv.ParseFloat(c.Value, 64)
+	case "created_at":
+		value, err = time.Parse(time.RFC3339Nano, c.Value)
 	}
+	if err != nil {
+		return nil, uuid.Nil, errInvalidCursor
+	}
+	return value, c.ID, nil
+}
 
-	count := 0
-	for {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
+// UpdateArticle saves the article. A price change is recorded in the price history
+// with the user (uuid.Nil for the system) and its source, and passed on to the variants.
+// Its attributes are validated, but a required attribute it never had is not enforced.
+func (s *ArticleService) UpdateArticle(article *models.Article, userID uuid.UUID, source models.PriceChangeSource) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var old models.Article
+		if err := tx.Select("price", "attributes").Where("id = ?", article.ID).First(&old).Error; err != nil {
+			return err
 		}
-		if err != nil {
-			return count, err
+		if old.Attributes == nil {
+			old.Attributes = models.Attributes{}
 		}
-
-		article := &models.Article{
-			AccountID: accountID,
-			Name:      record[headerMap["name"]],
+		attributes, err := validateAttributes(tx, article.AccountID, article.Attributes, old.Attributes)
+		if err != nil {
+			return err
 		}
-
-		if idx, ok := headerMap["code"]; ok {
-			article.Code = record[idx]
-		} else if idx, ok := headerMap["sku"]; ok {
-			article.Code = record[idx]
+		article.Attributes = attributes
+		if err := tx.Save(article).Error; err != nil {
+			return err
 		}
-		if idx, ok := headerMap["description"]; ok {
-			article.Description = record[idx]
+		oldPrice := old.Price
+		if oldPrice == article.Price {
+			return nil
 		}
 
-		// For import, we don't set initial stock or shop for now
-		if err := s.CreateArticle(article, 0, nil, uuid.Nil); err != nil {
-			// In a real app, we might want to collect errors and continue
-			return count, err
+		if err := recordPriceChange(tx, article, oldPrice, userID, source, nil); err != nil {
+			return err
 		}
-		count++
-	}
-
-	return count, nil
+		if article.ParentID == nil {
+			return syncVariantPrices(tx, article, userID)
+		}
+		return nil
+	})
 }
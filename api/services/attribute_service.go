@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"stock_management/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+// attributeKeyPattern is the accepted form of attribute keys: they appear in query
+// parameters (attr[key]) and import headers (attr:key).
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+const attributeDateLayout = "2006-01-02"
+
+func (s *ArticleService) GetAttributeDefinitions(accountID uuid.UUID) ([]models.AttributeDefinition, error) {
+	return attributeDefinitions(s.DB, accountID)
+}
+
+func attributeDefinitions(db *gorm.DB, accountID uuid.UUID) ([]models.AttributeDefinition, error) {
+	var defs []models.AttributeDefinition
+	err := db.Where("account_id = ?", accountID).Order("position, label").Find(&defs).Error
+	return defs, err
+}
+
+// CreateAttributeDefinition adds a custom attribute to the articles of the account.
+// A required attribute only applies to articles created from then on.
+func (s *ArticleService) CreateAttributeDefinition(def *models.AttributeDefinition) error {
+	def.Key = strings.ToLower(strings.TrimSpace(def.Key))
+	if !attributeKeyPattern.MatchString(def.Key) {
+		return fmt.Errorf("%w: the key must start with a letter and hold only lowercase letters, digits and underscores", ErrInvalidAttribute)
+	}
+	switch def.Type {
+	case models.AttributeText, models.AttributeNumber, models.AttributeEnum, models.AttributeDate:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAttribute, def.Type)
+	}
+	if err := prepareAttributeDefinition(def); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.AttributeDefinition{}).Where("account_id = ? AND key = ?", def.AccountID, def.Key).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: attribute %q already exists", ErrInvalidAttribute, def.Key)
+	}
+	return s.DB.Create(def).Error
+}
+
+// UpdateAttributeDefinition changes the label, options, required flag and position of
+// an attribute. Its key and type are fixed: articles hold values of that type under
+// that key. Article values no longer among the options are kept until the article is edited.
+func (s *ArticleService) UpdateAttributeDefinition(accountID, defID uuid.UUID, update *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	var def models.AttributeDefinition
+	if err := s.DB.Where("id = ? AND account_id = ?", defID, accountID).First(&def).Error; err != nil {
+		return nil, err
+	}
+
+	def.Label = update.Label
+	def.Options = update.Options
+	def.Required = update.Required
+	def.Position = update.Position
+	if err := prepareAttributeDefinition(&def); err != nil {
+		return nil, err
+	}
+	return &def, s.DB.Model(&def).Select("label", "options", "required", "position").Updates(&def).Error
+}
+
+// prepareAttributeDefinition checks the label and the options of an enum, and drops
+// the options of other types.
+func prepareAttributeDefinition(def *models.AttributeDefinition) error {
+	def.Label = strings.TrimSpace(def.Label)
+	if def.Label == "" {
+		def.Label = def.Key
+	}
+	if def.Type != models.AttributeEnum {
+		def.Options = nil
+		return nil
+	}
+
+	options := make(models.StringList, 0, len(def.Options))
+	seen := make(map[string]bool, len(def.Options))
+	for _, option := range def.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[strings.ToLower(option)] {
+			continue
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return fmt.Errorf("%w: an enum needs at least one option", ErrInvalidAttribute)
+	}
+	def.Options = options
+	return nil
+}
+
+// DeleteAttributeDefinition removes an attribute and its values from every article.
+func (s *ArticleService) DeleteAttributeDefinition(accountID, defID uuid.UUID) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var def models.AttributeDefinition
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", defID, accountID).First(&def).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Article{}).Where("account_id = ? AND attributes->?::text IS NOT NULL", accountID, def.Key).
+			Update("attributes", gorm.Expr("attributes - ?::text", def.Key)).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&def).Error
+	})
+}
+
+// validateAttributes checks the attribute values of an article against the definitions
+// of its account and normalizes them: numbers parsed, enum options and dates in their
+// canonical form, empty values dropped. Required attributes missing from attrs are an
+// error unless they are also missing from previous (an update of an article created
+// before the attribute became required).
+func validateAttributes(db *gorm.DB, accountID uuid.UUID, attrs, previous models.Attributes) (models.Attributes, error) {
+	defs, err := attributeDefinitions(db, accountID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*models.AttributeDefinition, len(defs))
+	for i := range defs {
+		byKey[defs[i].Key] = &defs[i]
+	}
+
+	result := make(models.Attributes, len(attrs))
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Report the same error for the same input
+	for _, key := range keys {
+		def, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttribute, key)
+		}
+		value, err := normalizeAttributeValue(def, attrs[key])
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			result[key] = value
+		}
+	}
+
+	for _, def := range defs {
+		if _, ok := result[def.Key]; !def.Required || ok {
+			continue
+		}
+		if _, had := previous[def.Key]; previous == nil || had {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, def.Label)
+		}
+	}
+	return result, nil
+}
+
+// normalizeAttributeValue converts a JSON or spreadsheet value to the stored form of
+// the attribute; nil and empty strings give nil (no value).
+func normalizeAttributeValue(def *models.AttributeDefinition, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	text, isText := value.(string)
+	if isText {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, nil
+		}
+	}
+
+	switch def.Type {
+	case models.AttributeText:
+		if !isText {
+			return nil, fmt.Errorf("%w: %s must be text", ErrInvalidAttribute, def.Label)
+		}
+		return text, nil
+	case models.AttributeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			f, err := strconv.ParseFloat(strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", ",", ".").Replace(text), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %q is not a number", ErrInvalidAttribute, def.Label, text)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, def.Label)
+	case models.AttributeEnum:
+		if isText {
+			for _, option := range def.Options {
+				if strings.EqualFold(option, text) {
+					return option, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, def.Label, strings.Join(def.Options, ", "))
+	case models.AttributeDate:
+		if isText {
+			if date, err := time.Parse(attributeDateLayout, text); err == nil {
+				return date.Format(attributeDateLayout), nil
+			}
+			if date, err := time.Parse(time.RFC3339, text); err == nil {
+				return date.Format(attributeDateLayout), nil
+			}
+			// Date cells of an .xlsx import arrive as raw serial numbers
+			if serial, err := strconv.ParseFloat(text, 64); err == nil && serial > 0 {
+				if date, err := excelize.ExcelDateToTime(serial, false); err == nil {
+					return date.Format(attributeDateLayout), nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%w: %s must be a date (YYYY-MM-DD)", ErrInvalidAttribute, def.Label)
+	}
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidAttribute, def.Type)
+}
+
+// AttributeFilter narrows ListArticles on a custom attribute. Value matches text
+// attributes by substring, enums by any of its comma-separated options, numbers and
+// dates exactly; Min and Max bound numbers and dates.
+type AttributeFilter struct {
+	Key   string
+	Value string
+	Min   string
+	Max   string
+}
+
+// applyAttributeFilters adds the attribute filters to an articles query.
+func applyAttributeFilters(db *gorm.DB, query *gorm.DB, accountID uuid.UUID, filters []AttributeFilter) (*gorm.DB, error) {
+	if len(filters) == 0 {
+		return query, nil
+	}
+	defs, err := attributeDefinitions(db, accountID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*models.AttributeDefinition, len(defs))
+	for i := range defs {
+		byKey[defs[i].Key] = &defs[i]
+	}
+
+	for _, f := range filters {
+		def, ok := byKey[f.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown attribute %q", ErrInvalidArticleFilter, f.Key)
+		}
+		if f.Value != "" {
+			switch def.Type {
+			case models.AttributeText:
+				query = query.Where("articles.attributes->>?::text ILIKE ?", def.Key, "%"+escapeLike(f.Value)+"%")
+			case models.AttributeEnum:
+				var options []string
+				for _, v := range strings.Split(f.Value, ",") {
+					option, err := normalizeAttributeValue(def, v)
+					if err != nil {
+						return nil, fmt.Errorf("%w: %v", ErrInvalidArticleFilter, err)
+					}
+					if option != nil {
+						options = append(options, option.(string))
+					}
+				}
+				if len(options) > 0 {
+					query = query.Where("articles.attributes->>?::text IN ?", def.Key, options)
+				}
+			default:
+				value, err := normalizeAttributeValue(def, f.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidArticleFilter, err)
+				}
+				// Containment compares numbers numerically and can use the GIN index
+				query = query.Where("articles.attributes @> jsonb_build_object(?::text, ?::jsonb)", def.Key, attributeJSON(value))
+			}
+		}
+
+		for _, bound := range []struct {
+			value, op string
+		}{{f.Min, ">="}, {f.Max, "<="}} {
+			if bound.value == "" {
+				continue
+			}
+			value, err := normalizeAttributeValue(def, bound.value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidArticleFilter, err)
+			}
+			switch def.Type {
+			case models.AttributeNumber:
+				query = query.Where("jsonb_typeof(articles.attributes->?::text) = 'number' AND (articles.attributes->>?::text)::numeric "+bound.op+" ?",
+					def.Key, def.Key, value)
+			case models.AttributeDate:
+				// YYYY-MM-DD strings sort as dates
+				query = query.Where("articles.attributes->>?::text "+bound.op+" ?", def.Key, value)
+			default:
+				return nil, fmt.Errorf("%w: %s has no range", ErrInvalidArticleFilter, def.Label)
+			}
+		}
+	}
+	return query, nil
+}
+
+// attributeJSON is the JSON literal of a normalized attribute value.
+func attributeJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
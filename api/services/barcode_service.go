@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"stock_management/utils"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrBarcodeTaken = errors.New("barcode is already assigned to an article")
+
+// BarcodeLookup is the result of a scan: the article and its stock.
+type BarcodeLookup struct {
+	Article     models.Article        `json:"article"`
+	Barcode     string                `json:"barcode"`
+	ShopID      *uuid.UUID            `json:"shop_id,omitempty"`
+	Quantity    float64               `json:"quantity"`
+	StockByShop map[uuid.UUID]float64 `json:"stock_by_shop"`
+}
+
+// prepareBarcodes validates the barcodes attached to a new article and fills
+// their type and account. The first barcode becomes the primary one.
+func prepareBarcodes(tx *gorm.DB, article *models.Article) error {
+	seen := make(map[string]bool)
+	for i := range article.Barcodes {
+		barcode := &article.Barcodes[i]
+		barcode.Code = strings.TrimSpace(barcode.Code)
+		barcodeType, err := utils.DetectBarcode(barcode.Code)
+		if err != nil {
+			return fmt.Errorf("barcode %q: %w", barcode.Code, err)
+		}
+		if seen[barcode.Code] {
+			return fmt.Errorf("barcode %q is listed twice", barcode.Code)
+		}
+		seen[barcode.Code] = true
+		if err := ensureBarcodeFree(tx, article.AccountID, barcode.Code); err != nil {
+			return err
+		}
+		barcode.AccountID = article.AccountID
+		barcode.Type = string(barcodeType)
+		barcode.IsPrimary = i == 0
+	}
+	return nil
+}
+
+func ensureBarcodeFree(tx *gorm.DB, accountID uuid.UUID, code string) error {
+	var count int64
+	if err := tx.Model(&models.ArticleBarcode{}).Where("account_id = ? AND code = ?", accountID, code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrBarcodeTaken, code)
+	}
+	return nil
+}
+
+// GenerateInternalEAN13 returns the next unused EAN-13 built from the account's
+// barcode prefix. The account row is locked so concurrent calls get distinct codes.
+func (s *ArticleService) GenerateInternalEAN13(accountID uuid.UUID) (string, error) {
+	var code string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "id = ?", accountID).Error; err != nil {
+			return err
+		}
+		prefix := account.BarcodePrefix
+		if prefix == "" {
+			prefix = "200"
+		}
+
+		for {
+			account.BarcodeSequence++
+			candidate, err := utils.BuildEAN13(prefix, account.BarcodeSequence)
+			if err != nil {
+				return err
+			}
+			if ensureBarcodeFree(tx, accountID, candidate) == nil {
+				code = candidate
+				break
+			}
+		}
+
+		return tx.Model(&account).Update("barcode_sequence", account.BarcodeSequence).Error
+	})
+	return code, err
+}
+
+func (s *ArticleService) GetBarcodes(accountID, articleID uuid.UUID) ([]models.ArticleBarcode, error) {
+	var barcodes []models.ArticleBarcode
+	err := s.DB.Where("account_id = ? AND article_id = ?", accountID, articleID).
+		Order("is_primary desc, created_at asc").Find(&barcodes).Error
+	return barcodes, err
+}
+
+// AddBarcode attaches a barcode to an article, generating an internal EAN-13 when code is empty.
+func (s *ArticleService) AddBarcode(accountID, articleID uuid.UUID, code string, isPrimary bool) (*models.ArticleBarcode, error) {
+	var barcode *models.ArticleBarcode
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var article models.Article
+		if err := tx.Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+			return err
+		}
+
+		code = strings.TrimSpace(code)
+		if code == "" {
+			generated, err := NewArticleService(tx).GenerateInternalEAN13(accountID)
+			if err != nil {
+				return err
+			}
+			code = generated
+		}
+		barcodeType, err := utils.DetectBarcode(code)
+		if err != nil {
+			return err
+		}
+		if err := ensureBarcodeFree(tx, accountID, code); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.ArticleBarcode{}).Where("article_id = ?", articleID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing == 0 {
+			isPrimary = true
+		}
+		if isPrimary {
+			if err := tx.Model(&models.ArticleBarcode{}).Where("article_id = ?", articleID).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		barcode = &models.ArticleBarcode{
+			AccountID: accountID,
+			ArticleID: articleID,
+			Code:      code,
+			Type:      string(barcodeType),
+			IsPrimary: isPrimary,
+		}
+		return tx.Create(barcode).Error
+	})
+	return barcode, err
+}
+
+func (s *ArticleService) RemoveBarcode(accountID, articleID, barcodeID uuid.UUID) error {
+	res := s.DB.Where("id = ? AND account_id = ? AND article_id = ?", barcodeID, accountID, articleID).
+		Delete(&models.ArticleBarcode{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindByBarcode resolves a scanned code to an article and its stock, in shopID
+// only when set. Codes are matched against the article barcodes, then Article.Code.
+// A UPC-A scan also matches the equivalent EAN-13 (leading zero).
+func (s *ArticleService) FindByBarcode(accountID uuid.UUID, code string, shopID *uuid.UUID) (*BarcodeLookup, error) {
+	code = strings.TrimSpace(code)
+	candidates := []string{code}
+	if len(code) == 12 && utils.ValidateUPCA(code) == nil {
+		candidates = append(candidates, "0"+code)
+	}
+
+	var article models.Article
+	err := s.DB.Preload("Barcodes").Preload("Units").Preload("Components").
+		Where("articles.account_id = ?", accountID).
+		Where("articles.id IN (SELECT article_id FROM article_barcodes WHERE account_id = ? AND code IN ?) OR articles.code IN ?",
+			accountID, candidates, candidates).
+		First(&article).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if article.IsKit {
+		availability, err := s.GetKitAvailability(accountID, article.ID, shopID)
+		if err != nil {
+			return nil, err
+		}
+		lookup := &BarcodeLookup{Article: article, Barcode: code, ShopID: shopID, Quantity: availability.Quantity, StockByShop: make(map[uuid.UUID]float64)}
+		for _, shop := range availability.Shops {
+			lookup.StockByShop[shop.ShopID] = shop.Quantity
+		}
+		lookup.Article.TotalStock = lookup.Quantity
+		return lookup, nil
+	}
+
+	var levels []models.StockLevel
+	query := s.DB.Where("article_id = ?", article.ID)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	if err := query.Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	lookup := &BarcodeLookup{Article: article, Barcode: code, ShopID: shopID, StockByShop: make(map[uuid.UUID]float64)}
+	for _, level := range levels {
+		lookup.StockByShop[level.ShopID] = level.Quantity
+		lookup.Quantity = roundQty(lookup.Quantity + level.Quantity)
+	}
+	lookup.Article.TotalStock = lookup.Quantity
+	return lookup, nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"stock_management/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidBulkUpdate = errors.New("invalid bulk update")
+
+// MaxBulkArticles caps the number of articles a bulk update can change at once.
+const MaxBulkArticles = 5000
+
+// Price adjustment modes.
+const (
+	PriceSet     = "set"     // Value is the new price
+	PricePercent = "percent" // Value is a percentage added to the price (negative for a discount)
+	PriceAmount  = "amount"  // Value is an amount added to the price
+)
+
+// PriceAdjustment computes the new price of an article from its current one. The result
+// is rounded to a multiple of RoundTo when set (25 gives 1 225, 1 250...) with Rounding
+// "nearest" (default), "up" or "down".
+type PriceAdjustment struct {
+	Mode     string
+	Value    float64
+	RoundTo  float64
+	Rounding string
+}
+
+// Apply returns the adjusted price.
+func (p *PriceAdjustment) Apply(price float64) float64 {
+	switch p.Mode {
+	case PricePercent:
+		price *= 1 + p.Value/100
+	case PriceAmount:
+		price += p.Value
+	default:
+		price = p.Value
+	}
+	if p.RoundTo > 0 {
+		steps := price / p.RoundTo
+		switch p.Rounding {
+		case "up":
+			steps = math.Ceil(steps - 1e-9)
+		case "down":
+			steps = math.Floor(steps + 1e-9)
+		default:
+			steps = math.Round(steps)
+		}
+		price = steps * p.RoundTo
+	}
+	return math.Round(price*100) / 100 // decimal(10,2)
+}
+
+// BulkArticlePatch is the change applied to every selected article; nil fields are kept.
+type BulkArticlePatch struct {
+	CategoryID   *uuid.UUID
+	BrandID      *uuid.UUID
+	MinThreshold *float64
+	Price        *PriceAdjustment
+}
+
+// BulkArticleSelection picks the articles of a bulk update: ArticleIDs when set,
+// otherwise every article matching Filter (its sort and pagination are ignored).
+type BulkArticleSelection struct {
+	ArticleIDs []uuid.UUID
+	Filter     *ArticleFilter
+}
+
+type BulkUpdateItem struct {
+	ArticleID uuid.UUID `json:"article_id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	Changed   bool      `json:"changed"`
+	Note      string    `json:"note,omitempty"`
+}
+
+type BulkUpdateReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Selected int              `json:"selected"`
+	Updated  int              `json:"updated"`
+	Items    []BulkUpdateItem `json:"items"`
+}
+
+// BulkUpdateArticles applies patch to the selected articles in a single transaction,
+// or only reports what would change when dryRun is set. Price changes are recorded in
+// the price history as manual changes by userID and passed on to the variants.
+// A variant following its parent's price is left to follow it when the parent is also
+// selected; otherwise it gets the new price as an override, as with a single update.
+func (s *ArticleService) BulkUpdateArticles(accountID, userID uuid.UUID, selection BulkArticleSelection, patch BulkArticlePatch, dryRun bool) (*BulkUpdateReport, error) {
+	if patch.CategoryID == nil && patch.BrandID == nil && patch.MinThreshold == nil && patch.Price == nil {
+		return nil, fmt.Errorf("%w: nothing to update", ErrInvalidBulkUpdate)
+	}
+	if patch.MinThreshold != nil && *patch.MinThreshold < 0 {
+		return nil, fmt.Errorf("%w: min threshold must not be negative", ErrInvalidBulkUpdate)
+	}
+	if p := patch.Price; p != nil {
+		switch p.Mode {
+		case PriceSet, PricePercent, PriceAmount:
+		default:
+			return nil, fmt.Errorf("%w: unknown price mode %q", ErrInvalidBulkUpdate, p.Mode)
+		}
+		switch p.Rounding {
+		case "", "nearest", "up", "down":
+		default:
+			return nil, fmt.Errorf("%w: unknown rounding %q", ErrInvalidBulkUpdate, p.Rounding)
+		}
+		if p.RoundTo < 0 {
+			return nil, fmt.Errorf("%w: rounding step must not be negative", ErrInvalidBulkUpdate)
+		}
+	}
+	for _, ref := range []struct {
+		name  string
+		model interface{}
+		id    *uuid.UUID
+	}{{"category", &models.Category{}, patch.CategoryID}, {"brand", &models.Brand{}, patch.BrandID}} {
+		if ref.id == nil {
+			continue
+		}
+		var count int64
+		if err := s.DB.Model(ref.model).Where("id = ? AND account_id = ?", *ref.id, accountID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: %s not found", ErrInvalidBulkUpdate, ref.name)
+		}
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool, len(selection.ArticleIDs))
+	for _, id := range selection.ArticleIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(selection.ArticleIDs) == 0 {
+		if selection.Filter == nil {
+			return nil, fmt.Errorf("%w: select articles by id or by filter", ErrInvalidBulkUpdate)
+		}
+		filter := *selection.Filter
+		filter.Page, filter.PageSize, filter.Cursor = 0, 0, ""
+		page, err := s.ListArticles(accountID, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Items {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) > MaxBulkArticles {
+		return nil, fmt.Errorf("%w: %d articles selected, at most %d can be updated at once", ErrInvalidBulkUpdate, len(ids), MaxBulkArticles)
+	}
+
+	report := &BulkUpdateReport{DryRun: dryRun, Items: []BulkUpdateItem{}}
+	if len(ids) == 0 {
+		return report, nil
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var articles []models.Article
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND account_id = ?", ids, accountID).
+			Order("code").Find(&articles).Error; err != nil {
+			return err
+		}
+		if len(articles) != len(ids) {
+			return fmt.Errorf("%w: one or more articles were not found", ErrInvalidBulkUpdate)
+		}
+		report.Selected = len(articles)
+
+		// New prices of the selected products, which their following variants take
+		productPrices := make(map[uuid.UUID]float64)
+		if patch.Price != nil {
+			for _, a := range articles {
+				if a.ParentID == nil {
+					productPrices[a.ID] = patch.Price.Apply(a.Price)
+				}
+			}
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			item := BulkUpdateItem{ArticleID: article.ID, Code: article.Code, Name: article.Name, OldPrice: article.Price, NewPrice: article.Price}
+			columns := []string{}
+
+			if patch.CategoryID != nil && (article.CategoryID == nil || *article.CategoryID != *patch.CategoryID) {
+				article.CategoryID = patch.CategoryID
+				columns = append(columns, "category_id")
+			}
+			if patch.BrandID != nil && (article.BrandID == nil || *article.BrandID != *patch.BrandID) {
+				article.BrandID = patch.BrandID
+				columns = append(columns, "brand_id")
+			}
+			if patch.MinThreshold != nil && article.MinThreshold != *patch.MinThreshold {
+				article.MinThreshold = *patch.MinThreshold
+				columns = append(columns, "min_threshold")
+			}
+			follows := false
+			if patch.Price != nil {
+				if article.ParentID != nil && !article.HasPriceOverride {
+					item.NewPrice, follows = productPrices[*article.ParentID]
+				}
+				if follows {
+					item.Note = "follows the price of its product"
+				} else {
+					item.NewPrice = patch.Price.Apply(article.Price)
+				}
+				if item.NewPrice < 0 {
+					return fmt.Errorf("%w: the price of %s would be negative", ErrInvalidBulkUpdate, article.Name)
+				}
+			}
+			priceChanged := item.NewPrice != item.OldPrice && !follows
+			if priceChanged {
+				article.Price = item.NewPrice
+				columns = append(columns, "price")
+				if article.ParentID != nil && !article.HasPriceOverride {
+					article.HasPriceOverride = true
+					columns = append(columns, "has_price_override")
+				}
+			}
+
+			item.Changed = len(columns) > 0 || item.NewPrice != item.OldPrice
+			report.Items = append(report.Items, item)
+			if !item.Changed {
+				continue
+			}
+			report.Updated++
+			if dryRun || len(columns) == 0 {
+				continue // A following variant is updated with its product
+			}
+
+			if err := tx.Model(article).Select(columns).Updates(article).Error; err != nil {
+				return err
+			}
+			if !priceChanged {
+				continue
+			}
+			if err := recordPriceChange(tx, article, item.OldPrice, userID, models.PriceChangeManual, nil); err != nil {
+				return err
+			}
+			if article.ParentID == nil {
+				if err := syncVariantPrices(tx, article, userID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
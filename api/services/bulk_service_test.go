@@ -0,0 +1,40 @@
+package services
+
+import "testing"
+
+func TestPriceAdjustmentApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		adjustment PriceAdjustment
+		price      float64
+		want       float64
+	}{
+		{"set", PriceAdjustment{Mode: PriceSet, Value: 1500}, 1237.5, 1500},
+		{"set to zero", PriceAdjustment{Mode: PriceSet}, 1237.5, 0},
+		{"percent", PriceAdjustment{Mode: PricePercent, Value: 10}, 1000, 1100},
+		{"percent discount", PriceAdjustment{Mode: PricePercent, Value: -15}, 999, 849.15},
+		{"amount", PriceAdjustment{Mode: PriceAmount, Value: -100}, 1237.5, 1137.5},
+		{"cents rounded", PriceAdjustment{Mode: PricePercent, Value: 1}, 10.01, 10.11},
+
+		{"nearest 25 by default, half up", PriceAdjustment{Mode: PriceSet, Value: 1237.5, RoundTo: 25}, 0, 1250},
+		{"nearest 25", PriceAdjustment{Mode: PriceSet, Value: 1237.5, RoundTo: 25, Rounding: "nearest"}, 0, 1250},
+		{"nearest 25, down", PriceAdjustment{Mode: PriceSet, Value: 1237.4, RoundTo: 25}, 0, 1225},
+		{"up to 25", PriceAdjustment{Mode: PriceSet, Value: 1237.5, RoundTo: 25, Rounding: "up"}, 0, 1250},
+		{"down to 25", PriceAdjustment{Mode: PriceSet, Value: 1237.5, RoundTo: 25, Rounding: "down"}, 0, 1225},
+		{"up keeps a multiple", PriceAdjustment{Mode: PriceSet, Value: 1250, RoundTo: 25, Rounding: "up"}, 0, 1250},
+		{"down keeps a multiple", PriceAdjustment{Mode: PriceSet, Value: 1250, RoundTo: 25, Rounding: "down"}, 0, 1250},
+		{"percent then nearest 25", PriceAdjustment{Mode: PricePercent, Value: 10, RoundTo: 25}, 1130, 1250},
+		// 1000 × 1.1 is 1100.0000000000002 in floating point
+		{"percent then up, exact", PriceAdjustment{Mode: PricePercent, Value: 10, RoundTo: 25, Rounding: "up"}, 1000, 1100},
+		{"percent then down, exact", PriceAdjustment{Mode: PricePercent, Value: -10, RoundTo: 5, Rounding: "down"}, 1250, 1125},
+		{"nearest 100", PriceAdjustment{Mode: PriceAmount, Value: 49, RoundTo: 100}, 1200, 1200},
+		{"up to 100", PriceAdjustment{Mode: PriceAmount, Value: 1, RoundTo: 100, Rounding: "up"}, 1200, 1300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.adjustment.Apply(tt.price); got != tt.want {
+				t.Errorf("Apply(%v) = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
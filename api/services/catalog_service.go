@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateName = errors.New("an entry with this name already exists")
+	ErrEntryInUse    = errors.New("entry is still used by articles")
+)
+
+// CatalogService manages the categories and brands articles are classified with.
+type CatalogService struct {
+	DB *gorm.DB
+}
+
+func NewCatalogService(db *gorm.DB) *CatalogService {
+	return &CatalogService{DB: db}
+}
+
+// Categories
+
+func (s *CatalogService) CreateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if err := s.checkUniqueName(&models.Category{}, category.AccountID, uuid.Nil, category.Name); err != nil {
+		return err
+	}
+	if err := s.checkParent(category); err != nil {
+		return err
+	}
+	prefix, err := NormalizeCodePrefix(category.CodePrefix)
+	if err != nil {
+		return err
+	}
+	category.CodePrefix = prefix
+	return duplicateNameError(s.DB.Create(category).Error)
+}
+
+func (s *CatalogService) GetCategoriesByAccount(accountID uuid.UUID, search string) ([]models.Category, error) {
+	var categories []models.Category
+	err := s.listQuery(accountID, search).Find(&categories).Error
+	return categories, err
+}
+
+// GetCategoryTree returns the account's root categories with their descendants nested in Children.
+func (s *CatalogService) GetCategoryTree(accountID uuid.UUID) ([]models.Category, error) {
+	categories, err := s.GetCategoriesByAccount(accountID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byParent := make(map[uuid.UUID][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			byParent[*category.ParentID] = append(byParent[*category.ParentID], category)
+		}
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(byParent[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots), nil
+}
+
+func (s *CatalogService) GetCategory(accountID, categoryID uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	if err := s.DB.Where("id = ? AND account_id = ?", categoryID, accountID).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *CatalogService) UpdateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if err := s.checkUniqueName(&models.Category{}, category.AccountID, category.ID, category.Name); err != nil {
+		return err
+	}
+	if err := s.checkParent(category); err != nil {
+		return err
+	}
+	prefix, err := NormalizeCodePrefix(category.CodePrefix)
+	if err != nil {
+		return err
+	}
+	category.CodePrefix = prefix
+	return duplicateNameError(s.DB.Save(category).Error)
+}
+
+// checkParent ensures the parent belongs to the account and is not the category
+// itself or one of its descendants.
+func (s *CatalogService) checkParent(category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if *category.ParentID == category.ID {
+		return errors.New("a category cannot be its own parent")
+	}
+	if _, err := s.GetCategory(category.AccountID, *category.ParentID); err != nil {
+		return fmt.Errorf("parent category: %w", err)
+	}
+	if category.ID == uuid.Nil {
+		return nil
+	}
+
+	var count int64
+	if err := s.DB.Raw("SELECT COUNT(*) FROM ("+models.CategorySubtreeSQL+") AS subtree WHERE id = ?", category.ID, *category.ParentID).
+		Scan(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a category cannot be moved under one of its descendants")
+	}
+	return nil
+}
+
+// DeleteCategory removes a category. Articles still using it block the deletion
+// unless reassignTo is set, in which case they are moved to that category first.
+// A nil reassignTo with clear set detaches the articles instead. Child categories
+// are moved up to the deleted category's parent.
+func (s *CatalogService) DeleteCategory(accountID, categoryID uuid.UUID, reassignTo *uuid.UUID, clear bool) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		category, err := NewCatalogService(tx).GetCategory(accountID, categoryID)
+		if err != nil {
+			return err
+		}
+		if reassignTo != nil {
+			if *reassignTo == categoryID {
+				return errors.New("cannot reassign articles to the category being deleted")
+			}
+			if _, err := NewCatalogService(tx).GetCategory(accountID, *reassignTo); err != nil {
+				return fmt.Errorf("target category: %w", err)
+			}
+		}
+		if err := reassignArticles(tx, "category_id", accountID, categoryID, reassignTo, clear); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", categoryID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Category{}, "id = ?", categoryID).Error
+	})
+}
+
+// Brands
+
+func (s *CatalogService) CreateBrand(brand *models.Brand) error {
+	brand.Name = strings.TrimSpace(brand.Name)
+	if err := s.checkUniqueName(&models.Brand{}, brand.AccountID, uuid.Nil, brand.Name); err != nil {
+		return err
+	}
+	return duplicateNameError(s.DB.Create(brand).Error)
+}
+
+func (s *CatalogService) GetBrandsByAccount(accountID uuid.UUID, search string) ([]models.Brand, error) {
+	var brands []models.Brand
+	err := s.listQuery(accountID, search).Find(&brands).Error
+	return brands, err
+}
+
+func (s *CatalogService) GetBrand(accountID, brandID uuid.UUID) (*models.Brand, error) {
+	var brand models.Brand
+	if err := s.DB.Where("id = ? AND account_id = ?", brandID, accountID).First(&brand).Error; err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+func (s *CatalogService) UpdateBrand(brand *models.Brand) error {
+	brand.Name = strings.TrimSpace(brand.Name)
+	if err := s.checkUniqueName(&models.Brand{}, brand.AccountID, brand.ID, brand.Name); err != nil {
+		return err
+	}
+	return duplicateNameError(s.DB.Save(brand).Error)
+}
+
+// DeleteBrand follows the same rules as DeleteCategory.
+func (s *CatalogService) DeleteBrand(accountID, brandID uuid.UUID, reassignTo *uuid.UUID, clear bool) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewCatalogService(tx).GetBrand(accountID, brandID); err != nil {
+			return err
+		}
+		if reassignTo != nil {
+			if *reassignTo == brandID {
+				return errors.New("cannot reassign articles to the brand being deleted")
+			}
+			if _, err := NewCatalogService(tx).GetBrand(accountID, *reassignTo); err != nil {
+				return fmt.Errorf("target brand: %w", err)
+			}
+		}
+		if err := reassignArticles(tx, "brand_id", accountID, brandID, reassignTo, clear); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Brand{}, "id = ?", brandID).Error
+	})
+}
+
+func (s *CatalogService) listQuery(accountID uuid.UUID, search string) *gorm.DB {
+	query := s.DB.Where("account_id = ?", accountID)
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(search)+"%")
+	}
+	return query.Order("name asc")
+}
+
+// checkUniqueName rejects a name already used (case-insensitively) by another entry of the account.
+func (s *CatalogService) checkUniqueName(model interface{}, accountID, excludeID uuid.UUID, name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	var count int64
+	query := s.DB.Model(model).Where("account_id = ? AND LOWER(name) = LOWER(?)", accountID, name)
+	if excludeID != uuid.Nil {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateName
+	}
+	return nil
+}
+
+// duplicateNameError turns a violation of the unique name indexes into ErrDuplicateName:
+// checkUniqueName cannot see an entry created concurrently.
+func duplicateNameError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(pgErr.ConstraintName == models.CategoryNameIndex || pgErr.ConstraintName == models.BrandNameIndex) {
+		return ErrDuplicateName
+	}
+	return err
+}
+
+// reassignArticles moves the articles referencing id through column to reassignTo,
+// detaches them when clear is set, or fails with ErrEntryInUse.
+func reassignArticles(tx *gorm.DB, column string, accountID, id uuid.UUID, reassignTo *uuid.UUID, clear bool) error {
+	query := tx.Model(&models.Article{}).Where("account_id = ? AND "+column+" = ?", accountID, id)
+
+	if reassignTo != nil {
+		return query.Update(column, *reassignTo).Error
+	}
+	if clear {
+		return query.Update(column, nil).Error
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w (%d articles)", ErrEntryInUse, count)
+	}
+	return nil
+}
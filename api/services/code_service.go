@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"stock_management/utils"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxCodeAttempts bounds the counter values skipped because an article already
+// uses the code (codes typed by hand or generated by the former random scheme).
+const maxCodeAttempts = 1000
+
+// ancestorPrefixSQL selects the code prefix of the nearest category, starting from
+// the given one (first parameter) and walking up its parents, that defines one.
+const ancestorPrefixSQL = `WITH RECURSIVE ancestors AS (
+	SELECT id, parent_id, code_prefix, 0 AS depth FROM categories WHERE id = ? AND account_id = ?
+	UNION ALL
+	SELECT categories.id, categories.parent_id, categories.code_prefix, ancestors.depth + 1
+	FROM categories JOIN ancestors ON categories.id = ancestors.parent_id
+) SELECT code_prefix FROM ancestors WHERE code_prefix <> '' ORDER BY depth LIMIT 1`
+
+// GenerateCode returns the next code of the account's scheme for an article of the
+// category: <prefix><separator><zero-padded counter>, followed by a GS1 mod-10
+// check digit when enabled. Each prefix has its own counter, incremented with an
+// upsert so that concurrent creations never get the same value; run in the
+// creating transaction, the counter is rolled back with it.
+func (s *ArticleService) GenerateCode(accountID uuid.UUID, categoryID *uuid.UUID) (string, error) {
+	var account models.Account
+	if err := s.DB.Select("code_prefix", "code_separator", "code_digits", "code_check_digit").
+		First(&account, "id = ?", accountID).Error; err != nil {
+		return "", err
+	}
+
+	prefix := account.CodePrefix
+	if categoryID != nil {
+		var categoryPrefix string
+		if err := s.DB.Raw(ancestorPrefixSQL, *categoryID, accountID).Scan(&categoryPrefix).Error; err != nil {
+			return "", err
+		}
+		if categoryPrefix != "" {
+			prefix = categoryPrefix
+		}
+	}
+
+	for i := 0; i < maxCodeAttempts; i++ {
+		value, err := nextSequenceValue(s.DB, accountID, prefix)
+		if err != nil {
+			return "", err
+		}
+
+		code, err := FormatArticleCode(prefix, account.CodeSeparator, account.CodeDigits, account.CodeCheckDigit, value)
+		if err != nil {
+			return "", err
+		}
+
+		var count int64
+		if err := s.DB.Unscoped().Model(&models.Article{}).Where("account_id = ? AND code = ?", accountID, code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not generate a unique code for prefix %s after %d attempts", prefix, maxCodeAttempts)
+}
+
+// nextSequenceValue increments the counter of the account named key and returns its new value.
+func nextSequenceValue(db *gorm.DB, accountID uuid.UUID, key string) (int64, error) {
+	var value int64
+	err := db.Raw(`INSERT INTO code_sequences (account_id, prefix, last_value) VALUES (?, ?, 1)
+		ON CONFLICT (account_id, prefix) DO UPDATE SET last_value = code_sequences.last_value + 1
+		RETURNING last_value`, accountID, key).Scan(&value).Error
+	return value, err
+}
+
+// FormatArticleCode builds a code from a counter value. Values wider than digits are not truncated.
+func FormatArticleCode(prefix, separator string, digits int, checkDigit bool, value int64) (string, error) {
+	number := fmt.Sprintf("%0*d", digits, value)
+	if checkDigit {
+		check, err := utils.GTINCheckDigit(number)
+		if err != nil {
+			return "", err
+		}
+		number += fmt.Sprint(check)
+	}
+	return prefix + separator + number, nil
+}
+
+// NormalizeCodePrefix upper-cases a code prefix and checks it only holds letters and digits.
+func NormalizeCodePrefix(prefix string) (string, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if len(prefix) > 10 {
+		return "", errors.New("code prefix must be at most 10 characters")
+	}
+	for _, r := range prefix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", errors.New("code prefix must only contain letters and digits")
+		}
+	}
+	return prefix, nil
+}
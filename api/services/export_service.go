@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"stock_management/models"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// XLSXContentType is the MIME type of .xlsx workbooks.
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// catalogExportColumns is the column layout shared by the exports and the import,
+// followed by one "attr:<key>" column per custom attribute. Variants carry the code of
+// their product; kits are exported as plain articles, without their components.
+var catalogExportColumns = []string{"code", "name", "description", "price", "cost_price", "min_threshold", "stock_unit", "category", "brand", "parent_code", "size", "color"}
+
+// ImportArticlesFromXLSX imports the first sheet of an .xlsx workbook.
+// It accepts the same columns as ImportArticleRows.
+func (s *ArticleService) ImportArticlesFromXLSX(accountID uuid.UUID, reader io.Reader, opts ImportOptions) (*ImportReport, error) {
+	f, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrInvalidImportFile
+	}
+	// Raw values: formatted cells would read "12 500 FCFA" instead of 12500
+	rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+	return s.ImportArticleRows(accountID, rows, opts)
+}
+
+// ExportArticlesXLSX writes the catalog of the account in the import layout.
+func (s *ArticleService) ExportArticlesXLSX(accountID uuid.UUID) ([]byte, error) {
+	catalog, err := exportArticles(s.DB, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := newExportSheet("Articles", catalog.columns())
+	for _, a := range catalog.articles {
+		sheet.addRow(catalog.row(&a))
+	}
+	return sheet.bytes()
+}
+
+// ExportArticlesCSV writes the catalog of the account in the import layout, as a
+// UTF-8 CSV file that Excel opens with its accents.
+func (s *ArticleService) ExportArticlesCSV(accountID uuid.UUID) ([]byte, error) {
+	catalog, err := exportArticles(s.DB, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xef\xbb\xbf")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(catalog.columns()); err != nil {
+		return nil, err
+	}
+	for _, a := range catalog.articles {
+		values := catalog.row(&a)
+		record := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// ExportStockLevelsXLSX writes the catalog with the quantity and cost value held
+// in each shop (only shopID when set). The "stock:<shop>" columns re-import as initial stock.
+func (s *StockService) ExportStockLevelsXLSX(accountID uuid.UUID, shopID *uuid.UUID) ([]byte, error) {
+	catalog, err := exportArticles(s.DB, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shops []models.Shop
+	query := s.DB.Where("account_id = ?", accountID).Order("name")
+	if shopID != nil {
+		query = query.Where("id = ?", *shopID)
+	}
+	if err := query.Find(&shops).Error; err != nil {
+		return nil, err
+	}
+
+	var levels []models.StockLevel
+	if err := s.DB.Joins("JOIN shops ON shops.id = stock_levels.shop_id").
+		Where("shops.account_id = ?", accountID).Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	byArticle := make(map[uuid.UUID]map[uuid.UUID]models.StockLevel)
+	for _, level := range levels {
+		if byArticle[level.ArticleID] == nil {
+			byArticle[level.ArticleID] = make(map[uuid.UUID]models.StockLevel)
+		}
+		byArticle[level.ArticleID][level.ShopID] = level
+	}
+
+	columns := catalog.columns()
+	for _, shop := range shops {
+		columns = append(columns, "stock:"+shop.Name, "value:"+shop.Name)
+	}
+	columns = append(columns, "total_stock", "total_value")
+
+	sheet := newExportSheet("Stock", columns)
+	for _, a := range catalog.articles {
+		row := catalog.row(&a)
+		totalQty, totalValue := 0.0, 0.0
+		for _, shop := range shops {
+			level, ok := byArticle[a.ID][shop.ID]
+			if !ok {
+				row = append(row, nil, nil)
+				continue
+			}
+			value := level.Quantity * level.AvgCost
+			row = append(row, level.Quantity, value)
+			totalQty = roundQty(totalQty + level.Quantity)
+			totalValue += value
+		}
+		sheet.addRow(append(row, totalQty, totalValue))
+	}
+	return sheet.bytes()
+}
+
+// catalogExport is the catalog of an account as exported: its articles, the names of
+// their categories and brands, the codes of the products of variants, and its custom attributes.
+type catalogExport struct {
+	articles   []models.Article
+	names      map[uuid.UUID]string
+	codes      map[uuid.UUID]string
+	attributes []models.AttributeDefinition
+}
+
+// exportArticles loads the articles of the account, with the names of their categories and brands.
+func exportArticles(db *gorm.DB, accountID uuid.UUID) (*catalogExport, error) {
+	var articles []models.Article
+	if err := db.Where("account_id = ?", accountID).Order("code").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	attributes, err := attributeDefinitions(db, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string)
+	for _, model := range []interface{}{&models.Category{}, &models.Brand{}} {
+		var rows []struct {
+			ID   uuid.UUID
+			Name string
+		}
+		if err := db.Model(model).Where("account_id = ?", accountID).Select("id, name").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			names[r.ID] = r.Name
+		}
+	}
+	codes := make(map[uuid.UUID]string, len(articles))
+	for _, a := range articles {
+		codes[a.ID] = a.Code
+	}
+	return &catalogExport{articles: articles, names: names, codes: codes, attributes: attributes}, nil
+}
+
+func (e *catalogExport) columns() []string {
+	columns := append([]string{}, catalogExportColumns...)
+	for _, def := range e.attributes {
+		columns = append(columns, "attr:"+def.Key)
+	}
+	return columns
+}
+
+func (e *catalogExport) row(a *models.Article) []interface{} {
+	category, brand := "", ""
+	if a.CategoryID != nil {
+		category = e.names[*a.CategoryID]
+	}
+	if a.BrandID != nil {
+		brand = e.names[*a.BrandID]
+	}
+	parentCode := ""
+	if a.ParentID != nil {
+		parentCode = e.codes[*a.ParentID]
+	}
+	row := []interface{}{a.Code, a.Name, a.Description, a.Price, a.CostPrice, a.MinThreshold, a.StockUnit, category, brand, parentCode, a.Size, a.Color}
+	for _, def := range e.attributes {
+		row = append(row, a.Attributes[def.Key])
+	}
+	return row
+}
+
+// exportSheet builds a single-sheet workbook, one row at a time.
+type exportSheet struct {
+	file *excelize.File
+	name string
+	row  int
+}
+
+func newExportSheet(name string, columns []string) *exportSheet {
+	f := excelize.NewFile()
+	f.SetSheetName("Sheet1", name)
+
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	_ = f.SetSheetRow(name, "A1", &header)
+	if style, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err == nil {
+		last, _ := excelize.CoordinatesToCellName(len(columns), 1)
+		_ = f.SetCellStyle(name, "A1", last, style)
+	}
+	_ = f.SetPanes(name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	return &exportSheet{file: f, name: name, row: 1}
+}
+
+func (s *exportSheet) addRow(values []interface{}) {
+	s.row++
+	cell, _ := excelize.CoordinatesToCellName(1, s.row)
+	_ = s.file.SetSheetRow(s.name, cell, &values)
+}
+
+func (s *exportSheet) bytes() ([]byte, error) {
+	defer s.file.Close()
+	var buf bytes.Buffer
+	if err := s.file.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,651 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"stock_management/models"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImportOptions controls an article import.
+type ImportOptions struct {
+	// DryRun validates every row and reports what would happen without writing anything.
+	DryRun bool
+	// ShopID receives the quantities of the "stock" column.
+	ShopID *uuid.UUID
+	// OnlyShopID, when set, rejects stock for any other shop (vendors).
+	OnlyShopID *uuid.UUID
+	UserID     uuid.UUID
+}
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+)
+
+// ImportRowResult is the outcome of one data row. Line is the line in the file (header is line 1).
+type ImportRowResult struct {
+	Line     int      `json:"line"`
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Action   string   `json:"action"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun            bool              `json:"dry_run"`
+	Created           int               `json:"created"`
+	Updated           int               `json:"updated"`
+	Skipped           int               `json:"skipped"`
+	CreatedCategories []string          `json:"created_categories"`
+	CreatedBrands     []string          `json:"created_brands"`
+	Rows              []ImportRowResult `json:"rows"`
+}
+
+// ErrInvalidImportFile is returned when the file itself cannot be imported
+// (unreadable, no header, no name or code column).
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// importColumns maps accepted header names to the article field they fill.
+var importColumns = map[string]string{
+	"code":          "code",
+	"sku":           "code",
+	"reference":     "code",
+	"name":          "name",
+	"nom":           "name",
+	"description":   "description",
+	"price":         "price",
+	"prix":          "price",
+	"cost_price":    "cost_price",
+	"cost":          "cost_price",
+	"min_threshold": "min_threshold",
+	"stock_unit":    "stock_unit",
+	"unit":          "stock_unit",
+	"unite":         "stock_unit",
+	"category":      "category",
+	"categorie":     "category",
+	"brand":         "brand",
+	"marque":        "brand",
+	"stock":         "stock",
+	"parent_code":   "parent_code",
+	"parent":        "parent_code",
+	"size":          "size",
+	"taille":        "size",
+	"color":         "color",
+	"colour":        "color",
+	"couleur":       "color",
+}
+
+// importRow is a parsed and validated data row.
+type importRow struct {
+	result     *ImportRowResult
+	values     map[string]string
+	attributes models.Attributes
+	existing   *models.Article
+	stock      map[uuid.UUID]float64
+}
+
+// ImportArticlesFromCSV imports articles from a CSV file (comma or semicolon separated).
+// See ImportArticleRows for the accepted columns.
+func (s *ArticleService) ImportArticlesFromCSV(accountID uuid.UUID, reader io.Reader, opts ImportOptions) (*ImportReport, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel writes a UTF-8 BOM
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		csvReader.Comma = ';'
+	}
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	return s.ImportArticleRows(accountID, rows, opts)
+}
+
+// ImportArticleRows imports articles from a header row followed by data rows.
+// Articles are matched by code: existing ones are updated with the non-empty cells,
+// the others are created. Unknown categories and brands are created. "stock"
+// puts initial stock in opts.ShopID, "stock:<shop name>" in the named shop;
+// initial stock only applies to created articles. "attr:<key>" columns hold the
+// custom attribute values. A row with a "parent_code" creates a variant ("size",
+// "color") of that product, found in the account or created by the file; variants
+// are written after the other rows, and these columns are ignored on existing articles.
+//
+// Invalid rows are skipped and reported; the valid ones are still imported.
+func (s *ArticleService) ImportArticleRows(accountID uuid.UUID, records [][]string, opts ImportOptions) (*ImportReport, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImportFile)
+	}
+
+	defs, err := attributeDefinitions(s.DB, accountID)
+	if err != nil {
+		return nil, err
+	}
+	columns, stockColumns, attributeColumns, err := s.importHeader(accountID, records[0], defs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, CreatedCategories: []string{}, CreatedBrands: []string{}}
+	rows := make([]*importRow, 0, len(records)-1)
+	codes := make([]string, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := &importRow{
+			result:     &ImportRowResult{Line: i + 2},
+			values:     make(map[string]string),
+			attributes: make(models.Attributes),
+			stock:      make(map[uuid.UUID]float64),
+		}
+		empty := true
+		for idx, field := range columns {
+			if idx < len(record) {
+				if v := strings.TrimSpace(record[idx]); v != "" {
+					row.values[field] = v
+					empty = false
+				}
+			}
+		}
+		for idx, shopID := range stockColumns {
+			if idx < len(record) && strings.TrimSpace(record[idx]) != "" {
+				empty = false
+				qty, err := parseImportFloat(record[idx])
+				if err != nil {
+					row.result.Errors = append(row.result.Errors, fmt.Sprintf("column %q: %v", records[0][idx], err))
+					continue
+				}
+				row.stock[shopID] += qty
+			}
+		}
+		for idx, def := range attributeColumns {
+			if idx < len(record) && strings.TrimSpace(record[idx]) != "" {
+				empty = false
+				value, err := normalizeAttributeValue(def, record[idx])
+				if err != nil {
+					row.result.Errors = append(row.result.Errors, fmt.Sprintf("column %q: %v", records[0][idx], err))
+					continue
+				}
+				row.attributes[def.Key] = value
+			}
+		}
+		if empty {
+			continue // Blank lines are ignored rather than reported
+		}
+		if code := row.values["code"]; code != "" {
+			codes = append(codes, code)
+		}
+		if code := row.values["parent_code"]; code != "" {
+			codes = append(codes, code)
+		}
+		rows = append(rows, row)
+	}
+
+	existing, err := s.articlesByCode(accountID, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]int)
+	for _, row := range rows {
+		validateImportRow(row, existing, seen)
+		if row.existing != nil {
+			continue
+		}
+		for _, def := range defs {
+			if _, ok := row.attributes[def.Key]; def.Required && !ok {
+				row.result.Errors = append(row.result.Errors, fmt.Sprintf("%s is required", def.Label))
+			}
+		}
+	}
+	validateImportParents(rows, existing)
+
+	categories, err := catalogNames(s.DB, &models.Category{}, accountID)
+	if err != nil {
+		return nil, err
+	}
+	brands, err := catalogNames(s.DB, &models.Brand{}, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		for _, row := range rows {
+			if len(row.result.Errors) == 0 {
+				noteNewCatalogEntry(report, categories, brands, row)
+			}
+			report.add(row.result)
+		}
+		return report, nil
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Products first, so that variants find them whatever their order in the file
+		for _, variants := range []bool{false, true} {
+			for _, row := range rows {
+				if _, isVariant := row.values["parent_code"]; isVariant != variants || len(row.result.Errors) > 0 {
+					continue
+				}
+				if err := s.applyImportRow(tx, accountID, row, existing, categories, brands, report, opts); err != nil {
+					row.result.Errors = append(row.result.Errors, err.Error())
+				}
+			}
+		}
+		for _, row := range rows {
+			report.add(row.result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *ImportReport) add(row *ImportRowResult) {
+	if len(row.Errors) > 0 {
+		row.Action = ImportSkipped
+	}
+	switch row.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Rows = append(r.Rows, *row)
+}
+
+// importHeader resolves the header row to article fields, stock columns to shops and
+// attribute columns to their definition.
+func (s *ArticleService) importHeader(accountID uuid.UUID, header []string, defs []models.AttributeDefinition, opts ImportOptions) (map[int]string, map[int]uuid.UUID, map[int]*models.AttributeDefinition, error) {
+	var shops []models.Shop
+	if err := s.DB.Where("account_id = ?", accountID).Find(&shops).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	columns := make(map[int]string)
+	stockColumns := make(map[int]uuid.UUID)
+	attributeColumns := make(map[int]*models.AttributeDefinition)
+	for idx, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if key, ok := strings.CutPrefix(name, "attr:"); ok {
+			key = strings.TrimSpace(key)
+			for i := range defs {
+				if defs[i].Key == key {
+					attributeColumns[idx] = &defs[i]
+				}
+			}
+			if attributeColumns[idx] == nil {
+				return nil, nil, nil, fmt.Errorf("%w: column %q: unknown attribute", ErrInvalidImportFile, h)
+			}
+			continue
+		}
+		if shopName, ok := strings.CutPrefix(name, "stock:"); ok {
+			shopName = strings.TrimSpace(shopName)
+			var shopID uuid.UUID
+			for _, shop := range shops {
+				if strings.EqualFold(shop.Name, shopName) {
+					shopID = shop.ID
+				}
+			}
+			if shopID == uuid.Nil {
+				return nil, nil, nil, fmt.Errorf("%w: column %q: unknown shop", ErrInvalidImportFile, h)
+			}
+			stockColumns[idx] = shopID
+			continue
+		}
+		field, ok := importColumns[name]
+		if !ok {
+			continue // Extra columns are ignored
+		}
+		if field == "stock" {
+			if opts.ShopID == nil {
+				return nil, nil, nil, fmt.Errorf("%w: the \"stock\" column requires a shop (shop_id)", ErrInvalidImportFile)
+			}
+			known := false
+			for _, shop := range shops {
+				known = known || shop.ID == *opts.ShopID
+			}
+			if !known {
+				return nil, nil, nil, fmt.Errorf("%w: unknown shop %s", ErrInvalidImportFile, opts.ShopID)
+			}
+			stockColumns[idx] = *opts.ShopID
+			continue
+		}
+		columns[idx] = field
+	}
+
+	hasKey := false
+	for _, field := range columns {
+		hasKey = hasKey || field == "code" || field == "name"
+	}
+	if !hasKey {
+		return nil, nil, nil, fmt.Errorf("%w: a \"code\" or \"name\" column is required", ErrInvalidImportFile)
+	}
+	if opts.OnlyShopID != nil {
+		for _, shopID := range stockColumns {
+			if shopID != *opts.OnlyShopID {
+				return nil, nil, nil, fmt.Errorf("%w: stock can only be imported in your shop", ErrInvalidImportFile)
+			}
+		}
+	}
+	return columns, stockColumns, attributeColumns, nil
+}
+
+func (s *ArticleService) articlesByCode(accountID uuid.UUID, codes []string) (map[string]*models.Article, error) {
+	result := make(map[string]*models.Article)
+	if len(codes) == 0 {
+		return result, nil
+	}
+
+	lowered := make([]string, len(codes))
+	for i, code := range codes {
+		lowered[i] = strings.ToLower(code)
+	}
+	// Codes match case-insensitively, as in validateImportRow
+	var articles []models.Article
+	if err := s.DB.Where("account_id = ? AND LOWER(code) IN ?", accountID, lowered).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		result[strings.ToLower(articles[i].Code)] = &articles[i]
+	}
+	return result, nil
+}
+
+// validateImportRow checks the cells of a row and fills its result.
+func validateImportRow(row *importRow, existing map[string]*models.Article, seen map[string]int) {
+	res := row.result
+	res.Code = row.values["code"]
+	res.Name = row.values["name"]
+
+	if res.Code != "" {
+		key := strings.ToLower(res.Code)
+		if line, ok := seen[key]; ok {
+			res.Errors = append(res.Errors, fmt.Sprintf("code %q already used on line %d", res.Code, line))
+		}
+		seen[key] = res.Line
+		row.existing = existing[key]
+	}
+
+	if row.existing != nil {
+		res.Action = ImportUpdated
+		if res.Name == "" {
+			res.Name = row.existing.Name
+		}
+		if len(row.stock) > 0 {
+			res.Warnings = append(res.Warnings, "article already exists: initial stock ignored")
+			row.stock = nil
+		}
+		if v, ok := row.values["stock_unit"]; ok && v != row.existing.StockUnit {
+			res.Warnings = append(res.Warnings, "article already exists: stock unit ignored (change it in the unit settings)")
+		}
+		delete(row.values, "stock_unit")
+		differs := func(field, current string) bool {
+			v, ok := row.values[field]
+			return ok && !strings.EqualFold(v, current)
+		}
+		if _, ok := row.values["parent_code"]; (ok && row.existing.ParentID == nil) ||
+			differs("size", row.existing.Size) || differs("color", row.existing.Color) {
+			res.Warnings = append(res.Warnings, "article already exists: parent, size and colour ignored")
+		}
+		delete(row.values, "parent_code")
+		delete(row.values, "size")
+		delete(row.values, "color")
+	} else {
+		res.Action = ImportCreated
+		_, isVariant := row.values["parent_code"]
+		if res.Name == "" && !isVariant { // A variant is named after its product
+			res.Errors = append(res.Errors, "name is required")
+		}
+		if !isVariant && (row.values["size"] != "" || row.values["color"] != "") {
+			res.Warnings = append(res.Warnings, "size and colour only apply to variants (parent_code): ignored")
+			delete(row.values, "size")
+			delete(row.values, "color")
+		}
+	}
+
+	for _, field := range []string{"price", "cost_price"} {
+		if v, ok := row.values[field]; ok {
+			if _, err := parseImportFloat(v); err != nil {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", field, err))
+			}
+		}
+	}
+	if v, ok := row.values["min_threshold"]; ok {
+		if _, err := parseImportFloat(v); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("min_threshold: %v", err))
+		}
+	}
+}
+
+// validateImportParents checks the product of the new variants: an article of the
+// account that is not a variant itself, or a product created by another row.
+func validateImportParents(rows []*importRow, existing map[string]*models.Article) {
+	created := make(map[string]bool)
+	for _, row := range rows {
+		if _, isVariant := row.values["parent_code"]; row.existing == nil && !isVariant && row.values["code"] != "" {
+			created[strings.ToLower(row.values["code"])] = true
+		}
+	}
+
+	for _, row := range rows {
+		parentCode, ok := row.values["parent_code"]
+		if !ok {
+			continue
+		}
+		res := row.result
+		if row.values["size"] == "" && row.values["color"] == "" {
+			res.Errors = append(res.Errors, "a variant needs a size or a colour")
+		}
+		key := strings.ToLower(parentCode)
+		if parent := existing[key]; parent != nil {
+			if parent.ParentID != nil {
+				res.Errors = append(res.Errors, fmt.Sprintf("parent %q is a variant itself", parentCode))
+			}
+		} else if !created[key] {
+			res.Errors = append(res.Errors, fmt.Sprintf("unknown parent %q", parentCode))
+		}
+	}
+}
+
+// applyImportRow writes a valid row; it runs in a savepoint so a failing row
+// does not abort the import, nor leave behind the category or brand it created.
+// Created articles are added to byCode, where variants find their product.
+func (s *ArticleService) applyImportRow(tx *gorm.DB, accountID uuid.UUID, row *importRow, byCode map[string]*models.Article, categories, brands map[string]uuid.UUID, report *ImportReport, opts ImportOptions) error {
+	var newCategory, newBrand *uuid.UUID // Recorded once the row is written
+	var newArticle *models.Article
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		categoryID, created, err := ensureCatalogEntry(categories, row.values["category"], func(name string) (uuid.UUID, error) {
+			category := models.Category{AccountID: accountID, Name: name}
+			err := tx.Create(&category).Error
+			return category.ID, err
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			newCategory = categoryID
+		}
+		brandID, created, err := ensureCatalogEntry(brands, row.values["brand"], func(name string) (uuid.UUID, error) {
+			brand := models.Brand{AccountID: accountID, Name: name}
+			err := tx.Create(&brand).Error
+			return brand.ID, err
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			newBrand = brandID
+		}
+
+		article := row.existing
+		if article == nil {
+			article = &models.Article{AccountID: accountID, Code: row.values["code"]}
+		}
+		if v, ok := row.values["name"]; ok {
+			article.Name = v
+		}
+		if v, ok := row.values["description"]; ok {
+			article.Description = v
+		}
+		if v, ok := row.values["price"]; ok {
+			price, _ := parseImportFloat(v)
+			if article.ParentID != nil && price != article.Price {
+				article.HasPriceOverride = true
+			}
+			article.Price = price
+		}
+		if v, ok := row.values["cost_price"]; ok {
+			article.CostPrice, _ = parseImportFloat(v)
+		}
+		if v, ok := row.values["min_threshold"]; ok {
+			article.MinThreshold, _ = parseImportFloat(v)
+		}
+		if v, ok := row.values["stock_unit"]; ok { // New articles only, see validateImportRow
+			article.StockUnit = v
+		}
+		if categoryID != nil {
+			article.CategoryID = categoryID
+		}
+		if brandID != nil {
+			article.BrandID = brandID
+		}
+		if len(row.attributes) > 0 {
+			attributes := make(models.Attributes, len(article.Attributes)+len(row.attributes))
+			for key, value := range article.Attributes {
+				attributes[key] = value
+			}
+			for key, value := range row.attributes {
+				attributes[key] = value
+			}
+			article.Attributes = attributes
+		}
+
+		articles := NewArticleService(tx)
+		if row.existing != nil {
+			return articles.UpdateArticle(article, opts.UserID, models.PriceChangeImport)
+		}
+
+		if parentCode, ok := row.values["parent_code"]; ok {
+			parent := byCode[strings.ToLower(parentCode)]
+			if parent == nil {
+				return fmt.Errorf("product %q was not imported", parentCode)
+			}
+			article.Size, article.Color = row.values["size"], row.values["color"]
+			_, hasPrice := row.values["price"]
+			article.HasPriceOverride = hasPrice && article.Price != parent.Price
+			if err := articles.CreateVariant(parent.ID, article, 0, nil, opts.UserID); err != nil {
+				return err
+			}
+			row.result.Name = article.Name
+		} else if err := articles.CreateArticle(article, 0, nil, opts.UserID); err != nil {
+			return err
+		}
+		newArticle = article
+		row.result.Code = article.Code
+		stockService := NewStockService(tx)
+		for shopID, qty := range row.stock {
+			if qty <= 0 {
+				continue
+			}
+			if _, err := stockService.RecordMovement(accountID, shopID, article.ID, opts.UserID, models.MovementIn, qty, "", article.CostPrice, "Initial Stock (import)", "import"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if newArticle != nil {
+		byCode[strings.ToLower(newArticle.Code)] = newArticle
+	}
+	if newCategory != nil {
+		categories[strings.ToLower(row.values["category"])] = *newCategory
+		report.CreatedCategories = append(report.CreatedCategories, row.values["category"])
+	}
+	if newBrand != nil {
+		brands[strings.ToLower(row.values["brand"])] = *newBrand
+		report.CreatedBrands = append(report.CreatedBrands, row.values["brand"])
+	}
+	return nil
+}
+
+// catalogNames maps the lowercased category or brand names of the account to their id.
+func catalogNames(db *gorm.DB, model interface{}, accountID uuid.UUID) (map[string]uuid.UUID, error) {
+	var entries []struct {
+		ID   uuid.UUID
+		Name string
+	}
+	if err := db.Model(model).Where("account_id = ?", accountID).Select("id, name").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[string]uuid.UUID, len(entries))
+	for _, e := range entries {
+		names[strings.ToLower(e.Name)] = e.ID
+	}
+	return names, nil
+}
+
+// ensureCatalogEntry returns the id of the named category or brand, creating it when
+// missing; created tells the caller to add it to names once its row is written.
+func ensureCatalogEntry(names map[string]uuid.UUID, name string, create func(name string) (uuid.UUID, error)) (id *uuid.UUID, created bool, err error) {
+	if name == "" {
+		return nil, false, nil
+	}
+	if id, ok := names[strings.ToLower(name)]; ok {
+		return &id, false, nil
+	}
+	newID, err := create(name)
+	if err != nil {
+		return nil, false, err
+	}
+	return &newID, true, nil
+}
+
+// noteNewCatalogEntry lists, in a dry run, the categories and brands the row would create.
+func noteNewCatalogEntry(report *ImportReport, categories, brands map[string]uuid.UUID, row *importRow) {
+	if name := row.values["category"]; name != "" {
+		if _, ok := categories[strings.ToLower(name)]; !ok {
+			categories[strings.ToLower(name)] = uuid.Nil
+			report.CreatedCategories = append(report.CreatedCategories, name)
+		}
+	}
+	if name := row.values["brand"]; name != "" {
+		if _, ok := brands[strings.ToLower(name)]; !ok {
+			brands[strings.ToLower(name)] = uuid.Nil
+			report.CreatedBrands = append(report.CreatedBrands, name)
+		}
+	}
+}
+
+// parseImportFloat parses amounts as typed in spreadsheets: "12 500", "12,5".
+func parseImportFloat(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", ",", ".").Replace(strings.TrimSpace(v))
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", v)
+	}
+	if f < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return f, nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"stock_management/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrKitMovement = errors.New("kits hold no stock of their own: only sales can be recorded on a kit")
+	ErrInvalidKit  = errors.New("invalid kit")
+)
+
+// kitShopAvailabilitySQL is the number of kits articles.id that the component stock of
+// a shop (the SQL expression shopSQL) can make: whole kits unless the kit is weighed.
+// It rounds like GetKitAvailability, to the precision of quantities before FLOOR.
+func kitShopAvailabilitySQL(shopSQL string) string {
+	return `(SELECT COALESCE(MIN(CASE WHEN articles.decimal_qty
+			THEN ROUND(GREATEST(COALESCE(component_levels.quantity, 0), 0) / kit_components.qty, 3)
+			ELSE FLOOR(ROUND(GREATEST(COALESCE(component_levels.quantity, 0), 0) / kit_components.qty, 3)) END), 0)
+		FROM kit_components
+		LEFT JOIN stock_levels component_levels ON component_levels.article_id = kit_components.component_id
+			AND component_levels.shop_id = ` + shopSQL + `
+		WHERE kit_components.kit_id = articles.id)`
+}
+
+// SetKitComponents replaces the bill of materials of an article. A non-empty list
+// makes it a kit, an empty one turns it back into a regular article.
+func (s *ArticleService) SetKitComponents(accountID, kitID uuid.UUID, components []models.KitComponent) (*models.Article, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var kit models.Article
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", kitID, accountID).First(&kit).Error; err != nil {
+			return err
+		}
+
+		if len(components) > 0 && !kit.IsKit {
+			var variants int64
+			if err := tx.Model(&models.Article{}).Where("parent_id = ?", kitID).Count(&variants).Error; err != nil {
+				return err
+			}
+			if variants > 0 {
+				return fmt.Errorf("%w: a product with variants cannot be a kit", ErrInvalidKit)
+			}
+			var stocked int64
+			if err := tx.Model(&models.StockLevel{}).Where("article_id = ? AND quantity <> 0", kitID).Count(&stocked).Error; err != nil {
+				return err
+			}
+			if stocked > 0 {
+				return fmt.Errorf("%w: bring the stock of %s to zero before turning it into a kit", ErrInvalidKit, kit.Name)
+			}
+			var used int64
+			if err := tx.Model(&models.KitComponent{}).Where("component_id = ?", kitID).Count(&used).Error; err != nil {
+				return err
+			}
+			if used > 0 {
+				return fmt.Errorf("%w: %s is a component of another kit", ErrInvalidKit, kit.Name)
+			}
+		}
+
+		seen := make(map[uuid.UUID]bool, len(components))
+		componentIDs := make([]uuid.UUID, 0, len(components))
+		for i := range components {
+			c := &components[i]
+			if c.ComponentID == kitID {
+				return fmt.Errorf("%w: a kit cannot contain itself", ErrInvalidKit)
+			}
+			if c.Qty <= 0 {
+				return fmt.Errorf("%w: component quantities must be positive", ErrInvalidKit)
+			}
+			if seen[c.ComponentID] {
+				return fmt.Errorf("%w: article %s appears on several lines", ErrInvalidKit, c.ComponentID)
+			}
+			seen[c.ComponentID] = true
+			componentIDs = append(componentIDs, c.ComponentID)
+			c.KitID = kitID
+			c.AccountID = accountID
+			c.Qty = roundQty(c.Qty)
+		}
+
+		if len(componentIDs) > 0 {
+			var articles []models.Article
+			if err := tx.Where("id IN ? AND account_id = ?", componentIDs, accountID).Find(&articles).Error; err != nil {
+				return err
+			}
+			if len(articles) != len(componentIDs) {
+				return fmt.Errorf("%w: one or more components were not found", ErrInvalidKit)
+			}
+			for _, a := range articles {
+				if a.IsKit {
+					return fmt.Errorf("%w: %s is itself a kit", ErrInvalidKit, a.Name)
+				}
+			}
+			var parents int64
+			if err := tx.Model(&models.Article{}).Where("parent_id IN ?", componentIDs).Count(&parents).Error; err != nil {
+				return err
+			}
+			if parents > 0 {
+				return fmt.Errorf("%w: use the variants of a product as components, not the product", ErrInvalidKit)
+			}
+		}
+
+		if err := tx.Where("kit_id = ?", kitID).Delete(&models.KitComponent{}).Error; err != nil {
+			return err
+		}
+		if len(components) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&components).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&kit).Update("is_kit", len(components) > 0).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var kit models.Article
+	if err := s.DB.Preload("Components.Component").First(&kit, "id = ?", kitID).Error; err != nil {
+		return nil, err
+	}
+	return &kit, nil
+}
+
+// recordKitSale takes qty kits out of a shop: every component leaves stock in its own
+// movement, linked to the kit movement, which is valued at the cost of its components.
+func recordKitSale(tx *gorm.DB, kit *models.Article, shopID, userID uuid.UUID, qty float64, unit string, unitQty float64, reason, deviceID string) (*models.StockMovement, error) {
+	var components []models.KitComponent
+	if err := tx.Preload("Component").Where("kit_id = ?", kit.ID).Find(&components).Error; err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("%w: %s has no components", ErrInvalidKit, kit.Name)
+	}
+	if qty <= 0 {
+		return nil, errors.New("kit quantity must be positive")
+	}
+
+	movement := &models.StockMovement{
+		ID:        uuid.New(),
+		AccountID: kit.AccountID,
+		ShopID:    shopID,
+		ArticleID: kit.ID,
+		UserID:    userID,
+		Type:      models.MovementOut,
+		Qty:       qty,
+		Unit:      unit,
+		UnitQty:   unitQty,
+		Reason:    reason,
+		DeviceID:  deviceID,
+	}
+	if err := tx.Create(movement).Error; err != nil {
+		return nil, err
+	}
+
+	stockService := NewStockService(tx)
+	cost := 0.0
+	for _, component := range components {
+		out, err := stockService.RecordMovement(kit.AccountID, shopID, component.ComponentID, userID, models.MovementOut,
+			roundQty(qty*component.Qty), "", 0, "Kit "+kit.Code+": "+reason, deviceID)
+		if err != nil {
+			name := component.ComponentID.String()
+			if component.Component != nil {
+				name = component.Component.Name
+			}
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		if err := tx.Model(out).Update("kit_movement_id", movement.ID).Error; err != nil {
+			return nil, err
+		}
+		cost += out.Qty * out.UnitCost
+	}
+
+	movement.UnitCost = cost / qty
+	return movement, tx.Model(movement).Update("unit_cost", movement.UnitCost).Error
+}
+
+// KitShopAvailability is the number of kits the component stock of a shop can make.
+type KitShopAvailability struct {
+	ShopID    uuid.UUID  `json:"shop_id"`
+	ShopName  string     `json:"shop_name"`
+	Quantity  float64    `json:"quantity"`
+	LimitedBy *uuid.UUID `json:"limited_by,omitempty"` // Component running out first
+}
+
+type KitAvailability struct {
+	KitID    uuid.UUID             `json:"kit_id"`
+	Quantity float64               `json:"quantity"`
+	Shops    []KitShopAvailability `json:"shops"`
+}
+
+// GetKitAvailability computes how many kits can be sold from the component stock of
+// each shop (of shopID only when set).
+func (s *ArticleService) GetKitAvailability(accountID, kitID uuid.UUID, shopID *uuid.UUID) (*KitAvailability, error) {
+	var kit models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", kitID, accountID).First(&kit).Error; err != nil {
+		return nil, err
+	}
+	if !kit.IsKit {
+		return nil, fmt.Errorf("%w: %s is not a kit", ErrInvalidKit, kit.Name)
+	}
+
+	var components []models.KitComponent
+	if err := s.DB.Where("kit_id = ?", kitID).Find(&components).Error; err != nil {
+		return nil, err
+	}
+	componentIDs := make([]uuid.UUID, 0, len(components))
+	for _, c := range components {
+		componentIDs = append(componentIDs, c.ComponentID)
+	}
+
+	var shops []models.Shop
+	shopQuery := s.DB.Where("account_id = ?", accountID).Order("name")
+	if shopID != nil {
+		shopQuery = shopQuery.Where("id = ?", *shopID)
+	}
+	if err := shopQuery.Find(&shops).Error; err != nil {
+		return nil, err
+	}
+
+	var levels []models.StockLevel
+	if len(componentIDs) > 0 {
+		if err := s.DB.Where("article_id IN ?", componentIDs).Find(&levels).Error; err != nil {
+			return nil, err
+		}
+	}
+	stock := make(map[uuid.UUID]map[uuid.UUID]float64)
+	for _, level := range levels {
+		if stock[level.ShopID] == nil {
+			stock[level.ShopID] = make(map[uuid.UUID]float64)
+		}
+		stock[level.ShopID][level.ArticleID] = level.Quantity
+	}
+
+	result := &KitAvailability{KitID: kitID, Shops: make([]KitShopAvailability, 0, len(shops))}
+	for _, shop := range shops {
+		entry := KitShopAvailability{ShopID: shop.ID, ShopName: shop.Name}
+		for i, c := range components {
+			// Rounded first: 0.3 / 0.1 is 2.999... in floating point
+			available := roundQty(max(stock[shop.ID][c.ComponentID], 0) / c.Qty)
+			if !kit.DecimalQty {
+				available = math.Floor(available)
+			}
+			if i == 0 || available < entry.Quantity {
+				entry.Quantity = available
+				entry.LimitedBy = &components[i].ComponentID
+			}
+		}
+		entry.Quantity = roundQty(entry.Quantity)
+		result.Quantity = roundQty(result.Quantity + entry.Quantity)
+		result.Shops = append(result.Shops, entry)
+	}
+	return result, nil
+}
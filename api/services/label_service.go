@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"stock_management/models"
+	"stock_management/utils"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
+)
+
+// MaxLabelsPerRequest caps the number of labels rendered by a single request.
+const MaxLabelsPerRequest = 5000
+
+type LabelSymbology string
+
+const (
+	SymbologyCode128 LabelSymbology = "code128"
+	SymbologyEAN13   LabelSymbology = "ean13"
+)
+
+// LabelLayout describes an A4 sheet of adhesive labels. Dimensions are in millimetres.
+type LabelLayout struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"label_width"`
+	LabelHeight float64 `json:"label_height"`
+	MarginTop   float64 `json:"margin_top"`
+	MarginLeft  float64 `json:"margin_left"`
+	GapX        float64 `json:"gap_x"`
+	GapY        float64 `json:"gap_y"`
+}
+
+// LabelLayouts are the supported A4 sheets, keyed by name.
+var LabelLayouts = map[string]LabelLayout{
+	"a4-21": {Name: "a4-21", Description: "21 labels 63.5 x 38.1 mm (Avery L7160)", Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 7.2, GapX: 2.5},
+	"a4-24": {Name: "a4-24", Description: "24 labels 70 x 37 mm", Columns: 3, Rows: 8, LabelWidth: 70, LabelHeight: 37, MarginTop: 0.5},
+	"a4-40": {Name: "a4-40", Description: "40 labels 52.5 x 29.7 mm", Columns: 4, Rows: 10, LabelWidth: 52.5, LabelHeight: 29.7},
+	"a4-65": {Name: "a4-65", Description: "65 labels 38.1 x 21.2 mm (Avery L7651)", Columns: 5, Rows: 13, LabelWidth: 38.1, LabelHeight: 21.2, MarginTop: 10.7, MarginLeft: 4.7, GapX: 2.5},
+	"a4-14": {Name: "a4-14", Description: "14 shelf labels 99.1 x 38.1 mm (Avery L7163)", Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 4.65, GapX: 2.5},
+}
+
+// LabelRequest is one article to print and the number of copies.
+type LabelRequest struct {
+	ArticleID uuid.UUID
+	Quantity  int
+}
+
+// Label is the content printed on one label.
+type Label struct {
+	Name      string
+	Price     float64
+	Code      string
+	Symbology LabelSymbology
+}
+
+type LabelService struct {
+	DB *gorm.DB
+}
+
+func NewLabelService(db *gorm.DB) *LabelService {
+	return &LabelService{DB: db}
+}
+
+// BuildLabels resolves the requested articles into the list of labels to print,
+// repeated by quantity. EAN-13 labels use Article.Code when it is a valid EAN-13,
+// then the article's EAN-13 barcodes, and fall back to Code 128 otherwise.
+func (s *LabelService) BuildLabels(accountID uuid.UUID, requests []LabelRequest, symbology LabelSymbology) ([]Label, error) {
+	if symbology == "" {
+		symbology = SymbologyCode128
+	}
+	if symbology != SymbologyCode128 && symbology != SymbologyEAN13 {
+		return nil, errors.New("invalid symbology, use code128 or ean13")
+	}
+
+	ids := make([]uuid.UUID, 0, len(requests))
+	total := 0
+	for _, r := range requests {
+		if r.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for article %s", r.ArticleID)
+		}
+		total += r.Quantity
+		ids = append(ids, r.ArticleID)
+	}
+	if total > MaxLabelsPerRequest {
+		return nil, fmt.Errorf("too many labels requested (max %d)", MaxLabelsPerRequest)
+	}
+
+	var articles []models.Article
+	if err := s.DB.Preload("Barcodes").Where("account_id = ? AND id IN ?", accountID, ids).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Article, len(articles))
+	for _, a := range articles {
+		byID[a.ID] = a
+	}
+
+	labels := make([]Label, 0, total)
+	for _, r := range requests {
+		article, ok := byID[r.ArticleID]
+		if !ok {
+			return nil, fmt.Errorf("article %s not found", r.ArticleID)
+		}
+		label := Label{Name: article.Name, Price: article.Price, Code: article.Code, Symbology: SymbologyCode128}
+		if symbology == SymbologyEAN13 {
+			if code, ok := articleEAN13(&article); ok {
+				label.Code = code
+				label.Symbology = SymbologyEAN13
+			}
+		}
+		for i := 0; i < r.Quantity; i++ {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+func articleEAN13(article *models.Article) (string, bool) {
+	if utils.ValidateEAN13(article.Code) == nil {
+		return article.Code, true
+	}
+	for _, primaryFirst := range []bool{true, false} {
+		for _, b := range article.Barcodes {
+			if b.IsPrimary == primaryFirst && b.Type == string(utils.BarcodeEAN13) {
+				return b.Code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// RenderPDF lays the labels out on A4 sheets. skip leaves the first positions of
+// the first sheet empty so partly used sheets can be reused.
+func (s *LabelService) RenderPDF(labels []Label, layoutName string, skip int) ([]byte, error) {
+	layout, ok := LabelLayouts[layoutName]
+	if !ok {
+		return nil, fmt.Errorf("unknown label layout %q", layoutName)
+	}
+	perPage := layout.Columns * layout.Rows
+	if skip < 0 || skip >= perPage {
+		skip = 0
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for i, label := range labels {
+		pos := (i + skip) % perPage
+		if i == 0 || pos == 0 {
+			pdf.AddPage()
+		}
+		col, row := pos%layout.Columns, pos/layout.Columns
+		x := layout.MarginLeft + float64(col)*(layout.LabelWidth+layout.GapX)
+		y := layout.MarginTop + float64(row)*(layout.LabelHeight+layout.GapY)
+		if err := drawLabel(pdf, tr, label, x, y, layout.LabelWidth, layout.LabelHeight); err != nil {
+			return nil, err
+		}
+	}
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawLabel(pdf *gofpdf.Fpdf, tr func(string) string, label Label, x, y, w, h float64) error {
+	padding := 2.0
+	if h < 25 {
+		padding = 1.2
+	}
+	innerW := w - 2*padding
+
+	// Sizes scale with the label height so small and large sheets stay readable
+	nameSize := clampFloat(h*0.28, 6, 11)
+	priceSize := clampFloat(h*0.38, 7, 16)
+	textSize := clampFloat(h*0.2, 5, 8)
+
+	pdf.SetFont("Helvetica", "", nameSize)
+	name := fitText(pdf, tr(label.Name), innerW)
+	pdf.SetXY(x+padding, y+padding)
+	pdf.CellFormat(innerW, nameSize*0.4, name, "", 0, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", priceSize)
+	pdf.SetXY(x+padding, y+padding+nameSize*0.45)
+	pdf.CellFormat(innerW, priceSize*0.4, tr(FormatPrice(label.Price)), "", 0, "L", false, 0, "")
+
+	top := y + padding + nameSize*0.45 + priceSize*0.45 + 0.5
+	textH := textSize * 0.4
+	barH := y + h - padding - textH - top
+	if barH < 4 {
+		return errors.New("label too small for a barcode")
+	}
+
+	bc, err := EncodeBarcode(label.Code, label.Symbology)
+	if err != nil {
+		return fmt.Errorf("article %q: %w", label.Name, err)
+	}
+	modules := bc.Bounds().Dx()
+	module := innerW / float64(modules)
+	if module > 0.5 {
+		module = 0.5
+	}
+	barX := x + padding + (innerW-module*float64(modules))/2
+
+	pdf.SetFillColor(0, 0, 0)
+	for m := 0; m < modules; {
+		if !isDark(bc.At(m, 0)) {
+			m++
+			continue
+		}
+		start := m
+		for m < modules && isDark(bc.At(m, 0)) {
+			m++
+		}
+		pdf.Rect(barX+float64(start)*module, top, float64(m-start)*module, barH, "F")
+	}
+
+	pdf.SetFont("Helvetica", "", textSize)
+	pdf.SetXY(x+padding, top+barH)
+	pdf.CellFormat(innerW, textH, label.Code, "", 0, "C", false, 0, "")
+	return nil
+}
+
+// EncodeBarcode encodes code in the given symbology as a 1D barcode.
+func EncodeBarcode(code string, symbology LabelSymbology) (barcode.Barcode, error) {
+	if symbology == SymbologyEAN13 {
+		return ean.Encode(code)
+	}
+	return code128.Encode(code)
+}
+
+func isDark(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r+g+b < 3*0x8000
+}
+
+// fitText shortens s with an ellipsis until it fits in width.
+func fitText(pdf *gofpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return strings.TrimSpace(s) + "..."
+}
+
+func clampFloat(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// FormatPrice formats an amount in FCFA with a space as thousands separator (12 500 FCFA).
+func FormatPrice(amount float64) string {
+	digits := strconv.FormatInt(int64(amount+0.5), 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(d)
+	}
+	return b.String() + " FCFA"
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"path"
+	"stock_management/models"
+	"stock_management/storage"
+	"strings"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/google/uuid"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// MediaURLPrefix is the API path serving stored files: the stored key follows it.
+const MediaURLPrefix = "/api/media/"
+
+const (
+	ThumbnailSize = 256 // Longest side of generated thumbnails, in pixels
+	// MaxImagePixels rejects images whose decoded size would exhaust memory
+	// (a few KB of PNG can declare a 50000x50000 canvas).
+	MaxImagePixels = 40_000_000
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image format: use JPEG, PNG, GIF or WebP")
+	ErrImageTooLarge    = errors.New("image is too large")
+)
+
+// imageExtensions lists the accepted content types, as sniffed from the file content.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type MediaService struct {
+	DB             *gorm.DB
+	Storage        storage.Storage
+	MaxUploadBytes int64
+}
+
+func NewMediaService(db *gorm.DB, store storage.Storage, maxUploadBytes int64) *MediaService {
+	return &MediaService{DB: db, Storage: store, MaxUploadBytes: maxUploadBytes}
+}
+
+// uploadedImage is a validated upload, ready to be stored.
+type uploadedImage struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Image       image.Image
+}
+
+// readImage reads the upload, enforcing the size limit, and checks that it is a
+// decodable image of an accepted type. The client-provided content type is ignored.
+func (s *MediaService) readImage(r io.Reader) (*uploadedImage, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxUploadBytes {
+		return nil, fmt.Errorf("%w: maximum %d MB", ErrImageTooLarge, s.MaxUploadBytes/(1<<20))
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	return &uploadedImage{Data: data, ContentType: contentType, Ext: ext, Image: img}, nil
+}
+
+// thumbnail scales img down to fit ThumbnailSize and encodes it as JPEG.
+// Transparent areas are flattened on white.
+func thumbnail(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/w)
+		} else {
+			w, h = max(1, w*ThumbnailSize/h), ThumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, xdraw.Src)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// put stores data under key and returns the URL it is served at.
+func (s *MediaService) put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	if err := s.Storage.Put(ctx, key, contentType, bytes.NewReader(data), int64(len(data))); err != nil {
+		return "", err
+	}
+	return MediaURLPrefix + key, nil
+}
+
+// UploadArticleImage stores the image of an article with its thumbnail and
+// replaces the previous ones.
+func (s *MediaService) UploadArticleImage(ctx context.Context, accountID, articleID uuid.UUID, r io.Reader) (*models.Article, error) {
+	var article models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+		return nil, err
+	}
+
+	upload, err := s.readImage(r)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := thumbnail(upload.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	base := path.Join("articles", accountID.String(), uuid.New().String())
+	imageURL, err := s.put(ctx, base+upload.Ext, upload.ContentType, upload.Data)
+	if err != nil {
+		return nil, err
+	}
+	thumbURL, err := s.put(ctx, base+"_thumb.jpg", "image/jpeg", thumb)
+	if err != nil {
+		s.deleteURL(ctx, imageURL)
+		return nil, err
+	}
+
+	oldImage, oldThumb := article.ImageURL, article.ThumbnailURL
+	if err := s.DB.Model(&article).Updates(map[string]interface{}{
+		"image_url":     imageURL,
+		"thumbnail_url": thumbURL,
+	}).Error; err != nil {
+		s.deleteURL(ctx, imageURL)
+		s.deleteURL(ctx, thumbURL)
+		return nil, err
+	}
+
+	s.releaseURL(ctx, oldImage)
+	s.releaseURL(ctx, oldThumb)
+	return &article, nil
+}
+
+// UploadBackgroundImage stores the background image of the account and returns its URL.
+func (s *MediaService) UploadBackgroundImage(ctx context.Context, accountID uuid.UUID, r io.Reader) (string, error) {
+	var account models.Account
+	if err := s.DB.First(&account, "id = ?", accountID).Error; err != nil {
+		return "", err
+	}
+
+	upload, err := s.readImage(r)
+	if err != nil {
+		return "", err
+	}
+
+	key := path.Join("accounts", accountID.String(), "background-"+uuid.New().String()+upload.Ext)
+	url, err := s.put(ctx, key, upload.ContentType, upload.Data)
+	if err != nil {
+		return "", err
+	}
+
+	oldImage := account.BackgroundImage
+	if err := s.DB.Model(&account).Update("background_image", url).Error; err != nil {
+		s.deleteURL(ctx, url)
+		return "", err
+	}
+
+	s.deleteURL(ctx, oldImage)
+	return url, nil
+}
+
+// Open returns the stored file served at MediaURLPrefix + key.
+func (s *MediaService) Open(ctx context.Context, key string) (*storage.Object, error) {
+	return s.Storage.Get(ctx, key)
+}
+
+// releaseURL deletes a replaced article file unless another article still uses it
+// (variants are created with the image of their parent).
+func (s *MediaService) releaseURL(ctx context.Context, url string) {
+	if !strings.HasPrefix(url, MediaURLPrefix) {
+		return
+	}
+	var count int64
+	if err := s.DB.Model(&models.Article{}).Where("image_url = ? OR thumbnail_url = ?", url, url).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	s.deleteURL(ctx, url)
+}
+
+// deleteURL removes the stored file behind url. URLs hosted elsewhere are left alone,
+// and failures only leave an orphaned file behind.
+func (s *MediaService) deleteURL(ctx context.Context, url string) {
+	key, ok := strings.CutPrefix(url, MediaURLPrefix)
+	if !ok || key == "" {
+		return
+	}
+	_ = s.Storage.Delete(ctx, key)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"stock_management/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrScheduledPriceNotPending = errors.New("scheduled price change was already applied or cancelled")
+
+// recordPriceChange adds a price history entry for an article whose Price was oldPrice.
+func recordPriceChange(tx *gorm.DB, article *models.Article, oldPrice float64, userID uuid.UUID, source models.PriceChangeSource, scheduledID *uuid.UUID) error {
+	change := &models.PriceChange{
+		AccountID:        article.AccountID,
+		ArticleID:        article.ID,
+		OldPrice:         oldPrice,
+		NewPrice:         article.Price,
+		Source:           source,
+		ScheduledPriceID: scheduledID,
+	}
+	if userID != uuid.Nil {
+		change.UserID = &userID
+	}
+	return tx.Create(change).Error
+}
+
+// GetPriceHistory returns the price changes of an article, newest first.
+func (s *ArticleService) GetPriceHistory(accountID, articleID uuid.UUID) ([]models.PriceChange, error) {
+	var changes []models.PriceChange
+	err := s.DB.Preload("User").
+		Where("account_id = ? AND article_id = ?", accountID, articleID).
+		Order("created_at DESC").Find(&changes).Error
+	return changes, err
+}
+
+// SchedulePriceChange plans a new price for the article at effectiveAt.
+func (s *ArticleService) SchedulePriceChange(accountID, articleID, userID uuid.UUID, newPrice float64, effectiveAt time.Time) (*models.ScheduledPrice, error) {
+	if newPrice < 0 {
+		return nil, errors.New("price must not be negative")
+	}
+	if !effectiveAt.After(time.Now()) {
+		return nil, errors.New("effective date must be in the future")
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Article{}).Where("id = ? AND account_id = ?", articleID, accountID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	scheduled := &models.ScheduledPrice{
+		AccountID:   accountID,
+		ArticleID:   articleID,
+		NewPrice:    newPrice,
+		EffectiveAt: effectiveAt,
+		Status:      models.ScheduledPricePending,
+		CreatedBy:   userID,
+	}
+	return scheduled, s.DB.Create(scheduled).Error
+}
+
+// GetScheduledPrices lists the scheduled changes of an article (of the account when
+// articleID is uuid.Nil), by effective date.
+func (s *ArticleService) GetScheduledPrices(accountID, articleID uuid.UUID, status models.ScheduledPriceStatus) ([]models.ScheduledPrice, error) {
+	var scheduled []models.ScheduledPrice
+	query := s.DB.Where("account_id = ?", accountID)
+	if articleID != uuid.Nil {
+		query = query.Where("article_id = ?", articleID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("effective_at").Find(&scheduled).Error
+	return scheduled, err
+}
+
+func (s *ArticleService) CancelScheduledPrice(accountID, scheduledID uuid.UUID) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var scheduled models.ScheduledPrice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", scheduledID, accountID).First(&scheduled).Error; err != nil {
+			return err
+		}
+		if scheduled.Status != models.ScheduledPricePending {
+			return ErrScheduledPriceNotPending
+		}
+		return tx.Model(&scheduled).Update("status", models.ScheduledPriceCancelled).Error
+	})
+}
+
+// ApplyDuePriceChanges applies the pending changes whose effective date has passed,
+// oldest first, and returns how many were applied. Rows are claimed with SKIP LOCKED
+// so that several API instances can run the scheduler. A change that cannot be applied
+// is marked failed with its error, so it does not hold back the next ones.
+func (s *ArticleService) ApplyDuePriceChanges(now time.Time) (int, error) {
+	applied := 0
+	for {
+		done := false
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			var scheduled models.ScheduledPrice
+			res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND effective_at <= ?", models.ScheduledPricePending, now).
+				Order("effective_at").Limit(1).Find(&scheduled)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				done = true
+				return nil
+			}
+
+			status := models.ScheduledPriceApplied
+			// Savepoint: a failing change is rolled back but the row is still marked
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				var article models.Article
+				err := tx.Where("id = ? AND account_id = ?", scheduled.ArticleID, scheduled.AccountID).First(&article).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					// The article was deleted in the meantime
+					status = models.ScheduledPriceCancelled
+					return nil
+				} else if err != nil {
+					return err
+				}
+				return applyScheduledPrice(tx, &article, &scheduled)
+			})
+			if err != nil {
+				log.Printf("price scheduler: scheduled price %s: %v", scheduled.ID, err)
+				return tx.Model(&scheduled).Updates(map[string]interface{}{
+					"status": models.ScheduledPriceFailed,
+					"error":  err.Error(),
+				}).Error
+			}
+			if status == models.ScheduledPriceCancelled {
+				return tx.Model(&scheduled).Update("status", status).Error
+			}
+
+			applied++
+			return tx.Model(&scheduled).Updates(map[string]interface{}{
+				"status":     status,
+				"applied_at": now,
+			}).Error
+		})
+		if err != nil || done {
+			return applied, err
+		}
+	}
+}
+
+// applyScheduledPrice sets the new price the way UpdateArticle does, crediting the
+// change to the user who scheduled it.
+func applyScheduledPrice(tx *gorm.DB, article *models.Article, scheduled *models.ScheduledPrice) error {
+	oldPrice := article.Price
+	if oldPrice == scheduled.NewPrice {
+		return nil
+	}
+
+	article.Price = scheduled.NewPrice
+	updates := map[string]interface{}{"price": article.Price}
+	if article.ParentID != nil {
+		article.HasPriceOverride = true
+		updates["has_price_override"] = true
+	}
+	if err := tx.Model(article).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if err := recordPriceChange(tx, article, oldPrice, scheduled.CreatedBy, models.PriceChangeScheduled, &scheduled.ID); err != nil {
+		return err
+	}
+	if article.ParentID == nil {
+		return syncVariantPrices(tx, article, scheduled.CreatedBy)
+	}
+	return nil
+}
+
+// RunPriceScheduler applies due price changes every interval until ctx is cancelled.
+func (s *ArticleService) RunPriceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.ApplyDuePriceChanges(time.Now()); err != nil {
+			log.Printf("price scheduler: %v", err)
+		} else if n > 0 {
+			log.Printf("price scheduler: %d scheduled price change(s) applied", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPriceListNotEffective = errors.New("price list is not effective at this date")
+	ErrInvalidPriceList      = errors.New("invalid price list")
+)
+
+// effectivePriceListSQL restricts price_lists to the lists effective at the given time.
+const effectivePriceListSQL = "price_lists.is_active AND (price_lists.valid_from IS NULL OR price_lists.valid_from <= ?) AND (price_lists.valid_to IS NULL OR price_lists.valid_to > ?)"
+
+// shopSellingPriceSQL is the current selling price of articles.id in stock_levels.shop_id:
+// the price of the shop's effective price list, or Article.Price.
+const shopSellingPriceSQL = `COALESCE((SELECT price_list_items.price FROM price_list_items
+	JOIN price_lists ON price_lists.id = price_list_items.price_list_id
+	WHERE price_list_items.article_id = articles.id AND price_lists.shop_id = stock_levels.shop_id
+	AND price_lists.is_active AND (price_lists.valid_from IS NULL OR price_lists.valid_from <= NOW())
+	AND (price_lists.valid_to IS NULL OR price_lists.valid_to > NOW())
+	ORDER BY price_lists.valid_from DESC NULLS LAST LIMIT 1), articles.price)`
+
+type PriceService struct {
+	DB *gorm.DB
+}
+
+func NewPriceService(db *gorm.DB) *PriceService {
+	return &PriceService{DB: db}
+}
+
+func (s *PriceService) CreatePriceList(list *models.PriceList) error {
+	if err := s.validatePriceList(list); err != nil {
+		return err
+	}
+	return s.DB.Omit(clause.Associations).Create(list).Error
+}
+
+// GetPriceLists returns the lists of the account, only those of shopID when set.
+func (s *PriceService) GetPriceLists(accountID uuid.UUID, shopID *uuid.UUID) ([]models.PriceList, error) {
+	var lists []models.PriceList
+	query := s.DB.Preload("Shop").Where("account_id = ?", accountID)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+	err := query.Order("name").Find(&lists).Error
+	return lists, err
+}
+
+func (s *PriceService) GetPriceList(accountID, listID uuid.UUID) (*models.PriceList, error) {
+	var list models.PriceList
+	err := s.DB.Preload("Shop").Preload("Items.Article").
+		Where("id = ? AND account_id = ?", listID, accountID).First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (s *PriceService) UpdatePriceList(list *models.PriceList) error {
+	if err := s.validatePriceList(list); err != nil {
+		return err
+	}
+	return s.DB.Model(list).Select("name", "shop_id", "valid_from", "valid_to", "is_active").Updates(list).Error
+}
+
+// DeletePriceList removes the list and its prices. Sales keep the price they were made at.
+func (s *PriceService) DeletePriceList(accountID, listID uuid.UUID) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND account_id = ?", listID, accountID).Delete(&models.PriceList{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("price_list_id = ?", listID).Delete(&models.PriceListItem{}).Error
+	})
+}
+
+func (s *PriceService) validatePriceList(list *models.PriceList) error {
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPriceList)
+	}
+	if list.ValidFrom != nil && list.ValidTo != nil && !list.ValidTo.After(*list.ValidFrom) {
+		return fmt.Errorf("%w: valid_to must be after valid_from", ErrInvalidPriceList)
+	}
+	if list.ShopID != nil {
+		var count int64
+		if err := s.DB.Model(&models.Shop{}).Where("id = ? AND account_id = ?", *list.ShopID, list.AccountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: shop not found", ErrInvalidPriceList)
+		}
+	}
+	return nil
+}
+
+// SetPriceListItems adds or replaces the prices of the given articles in the list.
+func (s *PriceService) SetPriceListItems(accountID, listID uuid.UUID, items []models.PriceListItem) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.PriceList{}).Where("id = ? AND account_id = ?", listID, accountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// One row per article (the last one wins): an upsert cannot touch a row twice
+		byArticle := make(map[uuid.UUID]int, len(items))
+		unique := make([]models.PriceListItem, 0, len(items))
+		articleIDs := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			if item.Price < 0 {
+				return fmt.Errorf("%w: prices must not be negative", ErrInvalidPriceList)
+			}
+			item.PriceListID = listID
+			if i, ok := byArticle[item.ArticleID]; ok {
+				unique[i] = item
+				continue
+			}
+			byArticle[item.ArticleID] = len(unique)
+			unique = append(unique, item)
+			articleIDs = append(articleIDs, item.ArticleID)
+		}
+		if len(unique) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.Article{}).Where("id IN ? AND account_id = ?", articleIDs, accountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(articleIDs) {
+			return fmt.Errorf("%w: unknown article", ErrInvalidPriceList)
+		}
+
+		return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "price_list_id"}, {Name: "article_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"price", "updated_at"}),
+		}).Create(&unique).Error
+	})
+}
+
+func (s *PriceService) RemovePriceListItem(accountID, listID, articleID uuid.UUID) error {
+	res := s.DB.Where("price_list_id = ? AND article_id = ? AND price_list_id IN (SELECT id FROM price_lists WHERE account_id = ?)",
+		listID, articleID, accountID).Delete(&models.PriceListItem{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ResolvedPrice is the selling price of an article and where it comes from.
+type ResolvedPrice struct {
+	ArticleID     uuid.UUID  `json:"article_id"`
+	Price         float64    `json:"price"`
+	BasePrice     float64    `json:"base_price"`
+	PriceListID   *uuid.UUID `json:"price_list_id"`
+	PriceListName string     `json:"price_list_name,omitempty"`
+}
+
+// ResolvePrice returns the selling price of an article at the given time, from the
+// first of: the chosen price list (priceListID), the shop's price list, Article.Price.
+// When several shop lists are effective, the one starting last wins.
+func (s *PriceService) ResolvePrice(accountID, articleID uuid.UUID, shopID, priceListID *uuid.UUID, at time.Time) (*ResolvedPrice, error) {
+	var article models.Article
+	if err := s.DB.Select("id", "price").Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+		return nil, err
+	}
+	resolved := &ResolvedPrice{ArticleID: article.ID, Price: article.Price, BasePrice: article.Price}
+
+	if priceListID != nil {
+		var list models.PriceList
+		if err := s.DB.Where("id = ? AND account_id = ?", *priceListID, accountID).First(&list).Error; err != nil {
+			return nil, err
+		}
+		if !list.EffectiveAt(at) {
+			return nil, ErrPriceListNotEffective
+		}
+	}
+	if priceListID == nil && shopID == nil {
+		return resolved, nil
+	}
+
+	query := s.DB.Table("price_list_items").
+		Select("price_list_items.price, price_lists.id, price_lists.name").
+		Joins("JOIN price_lists ON price_lists.id = price_list_items.price_list_id").
+		Where("price_list_items.article_id = ? AND price_lists.account_id = ?", articleID, accountID).
+		Where(effectivePriceListSQL, at, at)
+
+	switch {
+	case priceListID != nil && shopID != nil:
+		query = query.Where("price_lists.id = ? OR price_lists.shop_id = ?", *priceListID, *shopID).
+			Clauses(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "price_lists.id = ? DESC, price_lists.valid_from DESC NULLS LAST",
+				Vars: []interface{}{*priceListID},
+			}})
+	case priceListID != nil:
+		query = query.Where("price_lists.id = ?", *priceListID)
+	default:
+		query = query.Where("price_lists.shop_id = ?", *shopID).Order("price_lists.valid_from DESC NULLS LAST")
+	}
+
+	var match struct {
+		Price float64
+		ID    uuid.UUID
+		Name  string
+	}
+	res := query.Limit(1).Scan(&match)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		resolved.Price = match.Price
+		resolved.PriceListID = &match.ID
+		resolved.PriceListName = match.Name
+	}
+	return resolved, nil
+}
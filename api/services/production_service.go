@@ -0,0 +1,344 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidProduction     = errors.New("invalid production order")
+	ErrProductionNotEditable = errors.New("only draft production orders can be completed or cancelled")
+	ErrNothingProduced       = errors.New("a production order must produce something: cancel it instead")
+)
+
+const (
+	productionSequenceKey     = "#PRD" // Article code prefixes are alphanumeric: no clash in code_sequences
+	productionReferenceFormat = "PRD-%06d"
+)
+
+type ProductionService struct {
+	DB *gorm.DB
+}
+
+func NewProductionService(db *gorm.DB) *ProductionService {
+	return &ProductionService{DB: db}
+}
+
+// ProductionActual is what was actually consumed (waste included) or produced of an
+// article when completing an order, in its stock unit. Lines left out keep their plan.
+type ProductionActual struct {
+	ArticleID uuid.UUID
+	Qty       float64
+	WasteQty  float64 // Inputs only
+}
+
+// ProductionFilter narrows the orders returned by GetProductionOrders.
+type ProductionFilter struct {
+	ShopID *uuid.UUID
+	Status models.ProductionStatus
+}
+
+// CreateProductionOrder stores a draft order and gives it the next reference of the account.
+func (s *ProductionService) CreateProductionOrder(order *models.ProductionOrder) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateProduction(tx, order); err != nil {
+			return err
+		}
+
+		value, err := nextSequenceValue(tx, order.AccountID, productionSequenceKey)
+		if err != nil {
+			return err
+		}
+		order.Reference = fmt.Sprintf(productionReferenceFormat, value)
+		order.Status = models.ProductionDraft
+
+		inputs, outputs := order.Inputs, order.Outputs
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+		for i := range inputs {
+			inputs[i].ProductionOrderID = order.ID
+		}
+		for i := range outputs {
+			outputs[i].ProductionOrderID = order.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&inputs).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(&outputs).Error; err != nil {
+			return err
+		}
+		order.Inputs, order.Outputs = inputs, outputs
+		return nil
+	})
+}
+
+// validateProduction checks the shop and the lines of an order: stocked articles of the
+// account, positive planned quantities, and no article on both sides.
+func validateProduction(tx *gorm.DB, order *models.ProductionOrder) error {
+	if order.ExtraCost < 0 {
+		return fmt.Errorf("%w: extra cost cannot be negative", ErrInvalidProduction)
+	}
+	if len(order.Inputs) == 0 || len(order.Outputs) == 0 {
+		return fmt.Errorf("%w: at least one input and one output are required", ErrInvalidProduction)
+	}
+
+	var shopCount int64
+	if err := tx.Model(&models.Shop{}).Where("id = ? AND account_id = ?", order.ShopID, order.AccountID).Count(&shopCount).Error; err != nil {
+		return err
+	}
+	if shopCount == 0 {
+		return fmt.Errorf("%w: shop not found", ErrInvalidProduction)
+	}
+
+	inputs := make(map[uuid.UUID]bool, len(order.Inputs))
+	articleIDs := make([]uuid.UUID, 0, len(order.Inputs)+len(order.Outputs))
+	for i := range order.Inputs {
+		line := &order.Inputs[i]
+		if line.PlannedQty <= 0 {
+			return fmt.Errorf("%w: planned quantities must be positive", ErrInvalidProduction)
+		}
+		if inputs[line.ArticleID] {
+			return fmt.Errorf("%w: article %s appears on several input lines", ErrInvalidProduction, line.ArticleID)
+		}
+		inputs[line.ArticleID] = true
+		line.PlannedQty = roundQty(line.PlannedQty)
+		articleIDs = append(articleIDs, line.ArticleID)
+	}
+	outputs := make(map[uuid.UUID]bool, len(order.Outputs))
+	for i := range order.Outputs {
+		line := &order.Outputs[i]
+		if line.PlannedQty <= 0 || line.CostShare < 0 {
+			return fmt.Errorf("%w: planned quantities must be positive", ErrInvalidProduction)
+		}
+		if inputs[line.ArticleID] {
+			return fmt.Errorf("%w: article %s is both an input and an output", ErrInvalidProduction, line.ArticleID)
+		}
+		if outputs[line.ArticleID] {
+			return fmt.Errorf("%w: article %s appears on several output lines", ErrInvalidProduction, line.ArticleID)
+		}
+		outputs[line.ArticleID] = true
+		line.PlannedQty = roundQty(line.PlannedQty)
+		articleIDs = append(articleIDs, line.ArticleID)
+	}
+
+	var articles []models.Article
+	if err := tx.Where("id IN ? AND account_id = ?", articleIDs, order.AccountID).Find(&articles).Error; err != nil {
+		return err
+	}
+	if len(articles) != len(articleIDs) {
+		return fmt.Errorf("%w: one or more articles were not found", ErrInvalidProduction)
+	}
+	for _, a := range articles {
+		if a.IsKit {
+			return fmt.Errorf("%w: %s is a kit and holds no stock", ErrInvalidProduction, a.Name)
+		}
+	}
+	var parents int64
+	if err := tx.Model(&models.Article{}).Where("parent_id IN ?", articleIDs).Count(&parents).Error; err != nil {
+		return err
+	}
+	if parents > 0 {
+		return fmt.Errorf("%w: use the variants of a product, not the product", ErrInvalidProduction)
+	}
+	return nil
+}
+
+func (s *ProductionService) GetProductionOrder(accountID, orderID uuid.UUID) (*models.ProductionOrder, error) {
+	var order models.ProductionOrder
+	err := s.DB.Preload("Inputs.Article").Preload("Outputs.Article").
+		Where("id = ? AND account_id = ?", orderID, accountID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (s *ProductionService) GetProductionOrders(accountID uuid.UUID, filter ProductionFilter) ([]models.ProductionOrder, error) {
+	var orders []models.ProductionOrder
+	query := s.DB.Preload("Inputs.Article").Preload("Outputs.Article").
+		Where("account_id = ?", accountID)
+
+	if filter.ShopID != nil {
+		query = query.Where("shop_id = ?", *filter.ShopID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	err := query.Order("created_at desc").Find(&orders).Error
+	return orders, err
+}
+
+// CompleteProductionOrder consumes the inputs and stocks the outputs of a draft order
+// in a single transaction. Every movement carries the order reference. The cost of the
+// consumed inputs, waste included, plus ExtraCost is spread over the outputs by CostShare
+// (by produced quantity when no share is set) and becomes their incoming unit cost.
+func (s *ProductionService) CompleteProductionOrder(accountID, orderID, userID uuid.UUID, inputs, outputs []ProductionActual, deviceID string) (*models.ProductionOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.ProductionOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Inputs").Preload("Outputs").
+			Where("id = ? AND account_id = ?", orderID, accountID).
+			First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status != models.ProductionDraft {
+			return ErrProductionNotEditable
+		}
+
+		inputIDs := make([]uuid.UUID, 0, len(order.Inputs))
+		for _, line := range order.Inputs {
+			inputIDs = append(inputIDs, line.ArticleID)
+		}
+		outputIDs := make([]uuid.UUID, 0, len(order.Outputs))
+		for _, line := range order.Outputs {
+			outputIDs = append(outputIDs, line.ArticleID)
+		}
+		consumed, err := productionActuals(inputs, inputIDs)
+		if err != nil {
+			return err
+		}
+		produced, err := productionActuals(outputs, outputIDs)
+		if err != nil {
+			return err
+		}
+
+		stockService := NewStockService(tx)
+		reason := "Production " + order.Reference
+		order.InputCost, order.WasteCost = 0, 0
+		for i := range order.Inputs {
+			line := &order.Inputs[i]
+			line.ConsumedQty, line.WasteQty = line.PlannedQty, 0
+			if actual, ok := consumed[line.ArticleID]; ok {
+				line.ConsumedQty, line.WasteQty = roundQty(actual.Qty), roundQty(actual.WasteQty)
+			}
+			if line.WasteQty > line.ConsumedQty {
+				return fmt.Errorf("%w: waste exceeds the consumed quantity of article %s", ErrInvalidProduction, line.ArticleID)
+			}
+			if line.ConsumedQty > 0 {
+				out, err := stockService.RecordMovement(accountID, order.ShopID, line.ArticleID, userID, models.MovementOut,
+					line.ConsumedQty, "", 0, reason, deviceID)
+				if err != nil {
+					return fmt.Errorf("input %s: %w", line.ArticleID, err)
+				}
+				if err := tx.Model(out).Update("reference", order.Reference).Error; err != nil {
+					return err
+				}
+				line.UnitCost = out.UnitCost
+			}
+			order.InputCost += line.ConsumedQty * line.UnitCost
+			order.WasteCost += line.WasteQty * line.UnitCost
+		}
+
+		totalQty, totalShare := 0.0, 0.0
+		for i := range order.Outputs {
+			line := &order.Outputs[i]
+			line.ProducedQty = line.PlannedQty
+			if actual, ok := produced[line.ArticleID]; ok {
+				line.ProducedQty = roundQty(actual.Qty)
+			}
+			line.Yield = line.ProducedQty / line.PlannedQty
+			if line.ProducedQty > 0 {
+				totalQty += line.ProducedQty
+				totalShare += line.CostShare
+			}
+		}
+		if totalQty == 0 {
+			return ErrNothingProduced
+		}
+
+		totalCost := order.InputCost + order.ExtraCost
+		for i := range order.Outputs {
+			line := &order.Outputs[i]
+			if line.ProducedQty <= 0 {
+				continue
+			}
+			weight := line.ProducedQty / totalQty
+			if totalShare > 0 {
+				weight = line.CostShare / totalShare
+			}
+			line.UnitCost = totalCost * weight / line.ProducedQty
+			in, err := stockService.RecordMovement(accountID, order.ShopID, line.ArticleID, userID, models.MovementIn,
+				line.ProducedQty, "", line.UnitCost, reason, deviceID)
+			if err != nil {
+				return fmt.Errorf("output %s: %w", line.ArticleID, err)
+			}
+			if err := tx.Model(in).Update("reference", order.Reference).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range order.Inputs {
+			if err := tx.Model(&order.Inputs[i]).Select("consumed_qty", "waste_qty", "unit_cost").Updates(&order.Inputs[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range order.Outputs {
+			if err := tx.Model(&order.Outputs[i]).Select("produced_qty", "yield", "unit_cost").Updates(&order.Outputs[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		order.Status = models.ProductionCompleted
+		order.CompletedBy = &userID
+		order.CompletedAt = &now
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProductionOrder(accountID, orderID)
+}
+
+// productionActuals indexes the actual quantities by article, rejecting articles that
+// are not on the order and negative quantities.
+func productionActuals(actuals []ProductionActual, articleIDs []uuid.UUID) (map[uuid.UUID]ProductionActual, error) {
+	onOrder := make(map[uuid.UUID]bool, len(articleIDs))
+	for _, id := range articleIDs {
+		onOrder[id] = true
+	}
+	byArticle := make(map[uuid.UUID]ProductionActual, len(actuals))
+	for _, actual := range actuals {
+		if !onOrder[actual.ArticleID] {
+			return nil, fmt.Errorf("%w: article %s is not on this production order", ErrInvalidProduction, actual.ArticleID)
+		}
+		if actual.Qty < 0 || actual.WasteQty < 0 {
+			return nil, fmt.Errorf("%w: quantities cannot be negative", ErrInvalidProduction)
+		}
+		byArticle[actual.ArticleID] = actual
+	}
+	return byArticle, nil
+}
+
+// CancelProductionOrder cancels a draft order; completed orders have moved stock already.
+func (s *ProductionService) CancelProductionOrder(accountID, orderID uuid.UUID) (*models.ProductionOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.ProductionOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", orderID, accountID).
+			First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status != models.ProductionDraft {
+			return ErrProductionNotEditable
+		}
+
+		now := time.Now()
+		order.Status = models.ProductionCancelled
+		order.CancelledAt = &now
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProductionOrder(accountID, orderID)
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidOrderTransition = errors.New("invalid purchase order status transition")
+	ErrOrderNotEditable       = errors.New("only draft purchase orders can be modified")
+	ErrOverReceipt            = errors.New("received quantity exceeds ordered quantity")
+	ErrInvalidPurchaseOrder   = errors.New("invalid purchase order")
+)
+
+type PurchaseService struct {
+	DB *gorm.DB
+}
+
+func NewPurchaseService(db *gorm.DB) *PurchaseService {
+	return &PurchaseService{DB: db}
+}
+
+// ReceptionLine is the quantity of one ordered article received in a reception,
+// in the unit of its order line.
+type ReceptionLine struct {
+	ArticleID uuid.UUID
+	Qty       float64
+}
+
+// PurchaseOrderFilter narrows the purchase orders returned by GetPurchaseOrders.
+type PurchaseOrderFilter struct {
+	Status     models.OrderStatus
+	SupplierID uuid.UUID
+	From       *time.Time
+	To         *time.Time
+}
+
+// CreateDraftOrder stores a new draft order with its lines and computes its total.
+func (s *PurchaseService) CreateDraftOrder(order *models.PurchaseOrder) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.validateOrder(tx, order); err != nil {
+			return err
+		}
+
+		order.Status = models.OrderDraft
+		if order.OrderDate.IsZero() {
+			order.OrderDate = time.Now()
+		}
+		if err := prefillUnitPrices(tx, order); err != nil {
+			return err
+		}
+		order.ComputeTotal()
+
+		items := order.Items
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].PurchaseOrderID = order.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&items).Error; err != nil {
+			return err
+		}
+		order.Items = items
+		return nil
+	})
+}
+
+// UpdateDraftOrder replaces the supplier, notes and lines of a draft order.
+func (s *PurchaseService) UpdateDraftOrder(accountID, orderID uuid.UUID, update *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.PurchaseOrder
+		if err := tx.Where("id = ? AND account_id = ?", orderID, accountID).First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status != models.OrderDraft {
+			return ErrOrderNotEditable
+		}
+
+		update.AccountID = accountID
+		if err := s.validateOrder(tx, update); err != nil {
+			return err
+		}
+
+		if err := prefillUnitPrices(tx, update); err != nil {
+			return err
+		}
+
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderItem{}).Error; err != nil {
+			return err
+		}
+		items := update.Items
+		for i := range items {
+			items[i].ID = uuid.Nil
+			items[i].PurchaseOrderID = order.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&items).Error; err != nil {
+			return err
+		}
+
+		order.SupplierID = update.SupplierID
+		order.Notes = update.Notes
+		if !update.OrderDate.IsZero() {
+			order.OrderDate = update.OrderDate
+		}
+		order.Items = items
+		order.ComputeTotal()
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(accountID, orderID)
+}
+
+// validateOrder checks that the supplier and every article belong to the order's account,
+// and resolves the unit of each line: the article's purchase unit when none is given.
+func (s *PurchaseService) validateOrder(tx *gorm.DB, order *models.PurchaseOrder) error {
+	if len(order.Items) == 0 {
+		return fmt.Errorf("%w: a purchase order needs at least one line", ErrInvalidPurchaseOrder)
+	}
+
+	var count int64
+	if err := tx.Model(&models.Supplier{}).Where("id = ? AND account_id = ?", order.SupplierID, order.AccountID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: supplier not found", ErrInvalidPurchaseOrder)
+	}
+
+	articleIDs := make([]uuid.UUID, 0, len(order.Items))
+	seen := make(map[uuid.UUID]bool)
+	for _, item := range order.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: invalid quantity for article %s", ErrInvalidPurchaseOrder, item.ArticleID)
+		}
+		if item.UnitPrice < 0 {
+			return fmt.Errorf("%w: invalid unit price for article %s", ErrInvalidPurchaseOrder, item.ArticleID)
+		}
+		if seen[item.ArticleID] {
+			return fmt.Errorf("%w: article %s appears on several lines", ErrInvalidPurchaseOrder, item.ArticleID)
+		}
+		seen[item.ArticleID] = true
+		articleIDs = append(articleIDs, item.ArticleID)
+	}
+
+	var articles []models.Article
+	if err := tx.Where("id IN ? AND account_id = ?", articleIDs, order.AccountID).Find(&articles).Error; err != nil {
+		return err
+	}
+	if len(articles) != len(articleIDs) {
+		return fmt.Errorf("%w: one or more articles were not found", ErrInvalidPurchaseOrder)
+	}
+	byID := make(map[uuid.UUID]*models.Article, len(articles))
+	for i := range articles {
+		byID[articles[i].ID] = &articles[i]
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		article := byID[item.ArticleID]
+		if article.IsKit {
+			return fmt.Errorf("%w: order the components of %s", ErrKitMovement, article.Name)
+		}
+		unit := item.Unit
+		if unit == "" {
+			unit = article.PurchaseUnit
+		}
+		name, factor, err := unitFactor(tx, article, unit)
+		if err != nil {
+			return err
+		}
+		if _, err := toStockQty(article, item.Quantity, factor); err != nil {
+			return err
+		}
+		item.Unit, item.UnitFactor = name, factor
+	}
+	return nil
+}
+
+// prefillUnitPrices fills lines without a unit price from the supplier's last purchase
+// price, which is per stock unit.
+func prefillUnitPrices(tx *gorm.DB, order *models.PurchaseOrder) error {
+	var articleIDs []uuid.UUID
+	for _, item := range order.Items {
+		if item.UnitPrice == 0 {
+			articleIDs = append(articleIDs, item.ArticleID)
+		}
+	}
+	if len(articleIDs) == 0 {
+		return nil
+	}
+
+	var links []models.ArticleSupplier
+	if err := tx.Where("supplier_id = ? AND article_id IN ?", order.SupplierID, articleIDs).Find(&links).Error; err != nil {
+		return err
+	}
+	prices := make(map[uuid.UUID]float64, len(links))
+	for _, link := range links {
+		prices[link.ArticleID] = link.LastPurchasePrice
+	}
+	for i := range order.Items {
+		if order.Items[i].UnitPrice == 0 {
+			order.Items[i].UnitPrice = prices[order.Items[i].ArticleID] * order.Items[i].UnitFactor
+		}
+	}
+	return nil
+}
+
+func (s *PurchaseService) GetPurchaseOrder(accountID, orderID uuid.UUID) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	err := s.DB.Preload("Supplier").Preload("Items.Article").
+		Where("id = ? AND account_id = ?", orderID, accountID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (s *PurchaseService) GetPurchaseOrders(accountID uuid.UUID, filter PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	var orders []models.PurchaseOrder
+	query := s.DB.Preload("Supplier").Preload("Items.Article").
+		Where("account_id = ?", accountID)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SupplierID != uuid.Nil {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.From != nil {
+		query = query.Where("order_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("order_date <= ?", *filter.To)
+	}
+
+	err := query.Order("order_date desc, created_at desc").Find(&orders).Error
+	return orders, err
+}
+
+// SendOrder marks a draft order as sent to its supplier.
+func (s *PurchaseService) SendOrder(accountID, orderID uuid.UUID) (*models.PurchaseOrder, error) {
+	return s.transition(accountID, orderID, models.OrderSent)
+}
+
+// CancelOrder cancels an order that has not been fully received yet.
+func (s *PurchaseService) CancelOrder(accountID, orderID uuid.UUID) (*models.PurchaseOrder, error) {
+	return s.transition(accountID, orderID, models.OrderCancelled)
+}
+
+func (s *PurchaseService) transition(accountID, orderID uuid.UUID, next models.OrderStatus) (*models.PurchaseOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", orderID, accountID).
+			First(&order).Error; err != nil {
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(next) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidOrderTransition, order.Status, next)
+		}
+
+		now := time.Now()
+		order.Status = next
+		switch next {
+		case models.OrderSent:
+			order.SentAt = &now
+		case models.OrderCancelled:
+			order.CancelledAt = &now
+		}
+
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(accountID, orderID)
+}
+
+// ReceiveOrder posts a reception against a sent or partially received order.
+// Every line increments the item's ReceivedQty and records a MovementIn in the
+// given shop, converted with the unit factor the line was ordered with; the order then moves to partial or received in the same transaction.
+func (s *PurchaseService) ReceiveOrder(
+	accountID, orderID, shopID, userID uuid.UUID,
+	lines []ReceptionLine,
+	allowOverReceipt bool,
+	deviceID string,
+) (*models.PurchaseOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Where("id = ? AND account_id = ?", orderID, accountID).
+			First(&order).Error; err != nil {
+			return err
+		}
+
+		if order.Status != models.OrderSent && order.Status != models.OrderPartial {
+			return fmt.Errorf("%w: cannot receive a %s order", ErrInvalidOrderTransition, order.Status)
+		}
+
+		var shopCount int64
+		if err := tx.Model(&models.Shop{}).Where("id = ? AND account_id = ?", shopID, accountID).Count(&shopCount).Error; err != nil {
+			return err
+		}
+		if shopCount == 0 {
+			return fmt.Errorf("%w: shop not found", ErrInvalidPurchaseOrder)
+		}
+
+		itemsByArticle := make(map[uuid.UUID]*models.PurchaseOrderItem, len(order.Items))
+		for i := range order.Items {
+			itemsByArticle[order.Items[i].ArticleID] = &order.Items[i]
+		}
+
+		stockService := NewStockService(tx)
+		reason := fmt.Sprintf("PO Reception %s", order.ID.String()[:8])
+		for _, line := range lines {
+			item, ok := itemsByArticle[line.ArticleID]
+			if !ok {
+				return fmt.Errorf("%w: article %s is not on this purchase order", ErrInvalidPurchaseOrder, line.ArticleID)
+			}
+			if line.Qty <= 0 {
+				return fmt.Errorf("%w: invalid quantity for article %s", ErrInvalidPurchaseOrder, line.ArticleID)
+			}
+			if item.ReceivedQty+line.Qty > item.Quantity && !allowOverReceipt {
+				return fmt.Errorf("%w for article %s (ordered %g, already received %g)",
+					ErrOverReceipt, line.ArticleID, item.Quantity, item.ReceivedQty)
+			}
+
+			item.ReceivedQty = roundQty(item.ReceivedQty + line.Qty)
+			if err := tx.Model(item).Update("received_qty", item.ReceivedQty).Error; err != nil {
+				return err
+			}
+
+			if _, err := stockService.recordMovement(accountID, shopID, item.ArticleID, userID, models.MovementIn,
+				line.Qty, item.Unit, item.UnitFactor, item.UnitPrice, reason, deviceID); err != nil {
+				return err
+			}
+
+			// Keep the supplier's last purchase price (per stock unit) up to date
+			link := models.ArticleSupplier{
+				ArticleID:         item.ArticleID,
+				SupplierID:        order.SupplierID,
+				AccountID:         accountID,
+				LastPurchasePrice: item.UnitPrice / item.UnitFactor,
+				PackSize:          1,
+			}
+			if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "article_id"}, {Name: "supplier_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"last_purchase_price", "updated_at"}),
+			}).Create(&link).Error; err != nil {
+				return err
+			}
+		}
+
+		next := models.OrderReceived
+		for _, item := range order.Items {
+			if item.ReceivedQty < item.Quantity {
+				next = models.OrderPartial
+				break
+			}
+		}
+		if !order.Status.CanTransitionTo(next) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidOrderTransition, order.Status, next)
+		}
+
+		return tx.Model(&order).Update("status", next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(accountID, orderID)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"math"
+	"stock_management/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultReorderCoverage is the multiple of MinThreshold a shop is brought back to.
+const DefaultReorderCoverage = 2.0
+
+type reorderShortfall struct {
+	ArticleID    uuid.UUID
+	ShopID       uuid.UUID
+	Quantity     float64
+	MinThreshold float64
+	DecimalQty   bool
+}
+
+type supplierPrice struct {
+	ArticleID  uuid.UUID
+	SupplierID uuid.UUID
+	UnitPrice  float64
+	link       *models.ArticleSupplier
+}
+
+// UnassignedReorderLine is a shortfall for which no supplier could be determined.
+type UnassignedReorderLine struct {
+	ArticleID    uuid.UUID `json:"article_id"`
+	ArticleName  string    `json:"article_name"`
+	SuggestedQty float64   `json:"suggested_qty"` // In the stock unit
+}
+
+type ReorderResult struct {
+	Orders     []models.PurchaseOrder  `json:"orders"`
+	Unassigned []UnassignedReorderLine `json:"unassigned"`
+}
+
+// GenerateReorderProposals turns MinThreshold shortfalls into draft purchase orders,
+// one per supplier. Each shop is brought back to coverage × MinThreshold, minus
+// what is still outstanding on open orders. Articles are assigned to their
+// preferred supplier, or failing that the supplier they were last ordered from.
+// Quantities are rounded to the supplier's minimum order and pack size, then
+// ordered in the article's purchase unit.
+func (s *PurchaseService) GenerateReorderProposals(accountID, userID, shopID uuid.UUID, coverage float64) (*ReorderResult, error) {
+	if coverage < 1 {
+		coverage = DefaultReorderCoverage
+	}
+
+	var shortfalls []reorderShortfall
+	query := s.DB.Table("stock_levels").
+		Select("stock_levels.article_id, stock_levels.shop_id, stock_levels.quantity, articles.min_threshold, articles.decimal_qty").
+		Joins("JOIN articles ON articles.id = stock_levels.article_id").
+		Where("articles.account_id = ? AND articles.deleted_at IS NULL AND stock_levels.quantity < articles.min_threshold", accountID).
+		// Kits and products with variants are not purchased: their components and variants are
+		Where("NOT articles.is_kit AND NOT EXISTS (SELECT 1 FROM articles v WHERE v.parent_id = articles.id AND v.deleted_at IS NULL)")
+	if shopID != uuid.Nil {
+		query = query.Where("stock_levels.shop_id = ?", shopID)
+	}
+	if err := query.Scan(&shortfalls).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ReorderResult{Orders: []models.PurchaseOrder{}, Unassigned: []UnassignedReorderLine{}}
+	if len(shortfalls) == 0 {
+		return result, nil
+	}
+
+	// 1. Quantity needed per article across shops
+	needed := make(map[uuid.UUID]float64)
+	var articleIDs []uuid.UUID
+	for _, sf := range shortfalls {
+		target := sf.MinThreshold * coverage
+		if !sf.DecimalQty {
+			target = math.Ceil(target)
+		}
+		if _, ok := needed[sf.ArticleID]; !ok {
+			articleIDs = append(articleIDs, sf.ArticleID)
+		}
+		needed[sf.ArticleID] = roundQty(needed[sf.ArticleID] + target - sf.Quantity)
+	}
+
+	// 2. Deduct quantities still expected on open orders
+	var outstanding []struct {
+		ArticleID uuid.UUID
+		Qty       float64
+	}
+	if err := s.DB.Table("purchase_order_items").
+		Select("purchase_order_items.article_id, SUM((purchase_order_items.quantity - purchase_order_items.received_qty) * purchase_order_items.unit_factor) as qty").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Where("purchase_orders.account_id = ? AND purchase_orders.status IN ? AND purchase_order_items.article_id IN ?",
+			accountID, []models.OrderStatus{models.OrderDraft, models.OrderSent, models.OrderPartial}, articleIDs).
+		Group("purchase_order_items.article_id").
+		Scan(&outstanding).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range outstanding {
+		needed[o.ArticleID] = roundQty(needed[o.ArticleID] - o.Qty)
+	}
+
+	var articleList []models.Article
+	if err := s.DB.Where("id IN ?", articleIDs).Find(&articleList).Error; err != nil {
+		return nil, err
+	}
+	articles := make(map[uuid.UUID]*models.Article, len(articleList))
+	for i := range articleList {
+		articles[articleList[i].ID] = &articleList[i]
+	}
+
+	// 3. Resolve the supplier and purchase price of each article
+	suppliers, err := s.lastSupplierPrices(accountID, articleIDs)
+	if err != nil {
+		return nil, err
+	}
+	var preferred []models.ArticleSupplier
+	if err := s.DB.Joins("JOIN suppliers ON suppliers.id = article_suppliers.supplier_id AND suppliers.deleted_at IS NULL").
+		Where("article_suppliers.account_id = ? AND article_suppliers.is_preferred = ? AND article_suppliers.article_id IN ?", accountID, true, articleIDs).
+		Find(&preferred).Error; err != nil {
+		return nil, err
+	}
+	for i := range preferred {
+		link := &preferred[i]
+		suppliers[link.ArticleID] = supplierPrice{
+			ArticleID:  link.ArticleID,
+			SupplierID: link.SupplierID,
+			UnitPrice:  link.LastPurchasePrice,
+			link:       link,
+		}
+	}
+
+	bySupplier := make(map[uuid.UUID]*models.PurchaseOrder)
+	var supplierOrder []uuid.UUID
+	var unassignedIDs []uuid.UUID
+	for _, articleID := range articleIDs {
+		qty := needed[articleID]
+		if qty <= 0 {
+			continue
+		}
+		sp, ok := suppliers[articleID]
+		if !ok {
+			unassignedIDs = append(unassignedIDs, articleID)
+			continue
+		}
+		order, ok := bySupplier[sp.SupplierID]
+		if !ok {
+			order = &models.PurchaseOrder{
+				AccountID:  accountID,
+				SupplierID: sp.SupplierID,
+				CreatedBy:  userID,
+				Notes:      "Automatic reorder proposal",
+			}
+			bySupplier[sp.SupplierID] = order
+			supplierOrder = append(supplierOrder, sp.SupplierID)
+		}
+		if sp.link != nil {
+			qty = sp.link.RoundOrderQty(qty)
+		}
+		unit, factor, err := unitFactor(s.DB, articles[articleID], articles[articleID].PurchaseUnit)
+		if err != nil {
+			return nil, err
+		}
+		qty /= factor
+		if factor != 1 || !articles[articleID].DecimalQty {
+			qty = math.Ceil(roundQty(qty))
+		}
+		order.Items = append(order.Items, models.PurchaseOrderItem{
+			ArticleID:  articleID,
+			Quantity:   qty,
+			Unit:       unit,
+			UnitFactor: factor,
+			UnitPrice:  sp.UnitPrice * factor,
+		})
+	}
+
+	for _, articleID := range unassignedIDs {
+		result.Unassigned = append(result.Unassigned, UnassignedReorderLine{
+			ArticleID:    articleID,
+			ArticleName:  articles[articleID].Name,
+			SuggestedQty: needed[articleID],
+		})
+	}
+
+	// 4. Create the drafts in a single transaction
+	var createdIDs []uuid.UUID
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		service := NewPurchaseService(tx)
+		for _, supplierID := range supplierOrder {
+			order := bySupplier[supplierID]
+			if err := service.CreateDraftOrder(order); err != nil {
+				return err
+			}
+			createdIDs = append(createdIDs, order.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range createdIDs {
+		order, err := s.GetPurchaseOrder(accountID, id)
+		if err != nil {
+			return nil, err
+		}
+		result.Orders = append(result.Orders, *order)
+	}
+
+	return result, nil
+}
+
+// lastSupplierPrices returns, for each article, the supplier and unit price (per stock
+// unit) of its most recent non-cancelled purchase order line.
+func (s *PurchaseService) lastSupplierPrices(accountID uuid.UUID, articleIDs []uuid.UUID) (map[uuid.UUID]supplierPrice, error) {
+	var rows []supplierPrice
+	err := s.DB.Table("purchase_order_items").
+		Select("DISTINCT ON (purchase_order_items.article_id) purchase_order_items.article_id, purchase_orders.supplier_id, purchase_order_items.unit_price / purchase_order_items.unit_factor AS unit_price").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Joins("JOIN suppliers ON suppliers.id = purchase_orders.supplier_id AND suppliers.deleted_at IS NULL").
+		Where("purchase_orders.account_id = ? AND purchase_orders.status <> ? AND purchase_order_items.article_id IN ?",
+			accountID, models.OrderCancelled, articleIDs).
+		Order("purchase_order_items.article_id, purchase_orders.order_date DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[uuid.UUID]supplierPrice, len(rows))
+	for _, row := range rows {
+		prices[row.ArticleID] = row
+	}
+	return prices, nil
+}
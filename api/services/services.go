@@ -1,6 +1,8 @@
 package services
 
 import (
+	"stock_management/storage"
+
 	"gorm.io/gorm"
 )
 
@@ -9,21 +11,35 @@ type ServicesManager struct {
 	JWTSecret           string
 	StockService        *StockService
 	ArticleService      *ArticleService
+	CatalogService      *CatalogService
 	AccountService      *AccountService
 	SubscriptionService *SubscriptionService
 	ShopService         *ShopService
+	SupplierService     *SupplierService
+	LabelService        *LabelService
+	MediaService        *MediaService
+	PurchaseService     *PurchaseService
+	PriceService        *PriceService
+	ProductionService   *ProductionService
 	WhatsAppService     *WhatsAppService
 }
 
-func InitServices(db *gorm.DB, jwtSecret string) *ServicesManager {
+func InitServices(db *gorm.DB, jwtSecret string, store storage.Storage, maxUploadBytes int64) *ServicesManager {
 	return &ServicesManager{
 		DB:                  db,
 		JWTSecret:           jwtSecret,
 		StockService:        NewStockService(db),
 		ArticleService:      NewArticleService(db),
+		CatalogService:      NewCatalogService(db),
 		AccountService:      NewAccountService(db, jwtSecret),
 		SubscriptionService: NewSubscriptionService(db),
 		ShopService:         NewShopService(db),
+		SupplierService:     NewSupplierService(db),
+		LabelService:        NewLabelService(db),
+		MediaService:        NewMediaService(db, store, maxUploadBytes),
+		PurchaseService:     NewPurchaseService(db),
+		PriceService:        NewPriceService(db),
+		ProductionService:   NewProductionService(db),
 		WhatsAppService:     NewWhatsAppService(),
 	}
 }
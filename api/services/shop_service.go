@@ -26,6 +26,26 @@ func (s *ShopService) CreateShop(accountID uuid.UUID, name, location string) (*m
 	return shop, err
 }
 
+func (s *ShopService) GetShop(accountID, shopID uuid.UUID) (*models.Shop, error) {
+	var shop models.Shop
+	if err := s.DB.Where("id = ? AND account_id = ?", shopID, accountID).First(&shop).Error; err != nil {
+		return nil, err
+	}
+	return &shop, nil
+}
+
+// UpdatePrinter sets (or clears, with an empty address) the shop's label printer.
+func (s *ShopService) UpdatePrinter(accountID, shopID uuid.UUID, address string) error {
+	res := s.DB.Model(&models.Shop{}).Where("id = ? AND account_id = ?", shopID, accountID).Update("printer_address", address)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (s *ShopService) GetShopsByAccount(accountID uuid.UUID) ([]models.Shop, error) {
 	var shops []models.Shop
 	err := s.DB.Where("account_id = ?", accountID).Find(&shops).Error
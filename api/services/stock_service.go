@@ -1,14 +1,18 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"stock_management/models"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type StockService struct {
 	DB *gorm.DB
 }
@@ -18,20 +22,75 @@ func NewStockService(db *gorm.DB) *StockService {
 }
 
 // RecordMovement registers a stock movement and updates the stock level in a transaction.
+// qty is expressed in unit, one of the article's units (empty for its stock unit), and
+// is converted to the stock unit. unitCost is the purchase cost of one unit of an "in"
+// movement; when it is zero the shop's current average cost (or the article's CostPrice
+// for empty stock) is used. Outgoing movements are valued at the shop's weighted-average cost.
 func (s *StockService) RecordMovement(
 	accountID, shopID, articleID, userID uuid.UUID,
 	moveType models.MovementType,
-	qty int,
+	qty float64,
+	unit string,
+	unitCost float64,
+	reason, deviceID string,
+) (*models.StockMovement, error) {
+	return s.recordMovement(accountID, shopID, articleID, userID, moveType, qty, unit, 0, unitCost, reason, deviceID)
+}
+
+// recordMovement is RecordMovement with the factor of unit already known (a purchase
+// order line keeps the factor it was ordered with); a zero factor looks it up.
+func (s *StockService) recordMovement(
+	accountID, shopID, articleID, userID uuid.UUID,
+	moveType models.MovementType,
+	qty float64,
+	unit string,
+	factor float64,
+	unitCost float64,
 	reason, deviceID string,
 ) (*models.StockMovement, error) {
 	var movement *models.StockMovement
 
 	err := s.DB.Transaction(func(tx *gorm.DB) error {
-		// 1. Get or create current stock level
+		var article models.Article
+		if err := tx.Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+			return err
+		}
+		var err error
+		unitName := unit
+		if factor <= 0 {
+			if unitName, factor, err = unitFactor(tx, &article, unit); err != nil {
+				return err
+			}
+		}
+		enteredQty := qty
+		if qty, err = toStockQty(&article, qty, factor); err != nil {
+			return err
+		}
+		unitCost /= factor
+
+		if article.IsKit {
+			if moveType != models.MovementOut {
+				return ErrKitMovement
+			}
+			movement, err = recordKitSale(tx, &article, shopID, userID, qty, unitName, enteredQty, reason, deviceID)
+			return err
+		}
+
+		var variantCount int64
+		if err := tx.Model(&models.Article{}).Where("parent_id = ?", articleID).Count(&variantCount).Error; err != nil {
+			return err
+		}
+		if variantCount > 0 {
+			return ErrVariantParent
+		}
+
+		// 1. Get or create current stock level, locked so that concurrent movements
+		// of the article in the shop update quantity and average cost one after the other
 		var stock models.StockLevel
-		res := tx.Where("article_id = ? AND shop_id = ?", articleID, shopID).First(&stock)
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("article_id = ? AND shop_id = ?", articleID, shopID).First(&stock)
 
-		oldQty := 0
+		oldQty := 0.0
 		if res.Error == nil {
 			oldQty = stock.Quantity
 		} else if errors.Is(res.Error, gorm.ErrRecordNotFound) {
@@ -44,16 +103,25 @@ func (s *StockService) RecordMovement(
 			return res.Error
 		}
 
-		// 2. Calculate new quantity
+		// 2. Calculate new quantity and cost
 		newQty := oldQty
+		movementCost := stock.AvgCost
 		switch moveType {
 		case models.MovementIn:
-			newQty += qty
+			if unitCost <= 0 {
+				unitCost = stock.AvgCost
+				if oldQty <= 0 || unitCost == 0 {
+					unitCost = article.CostPrice
+				}
+			}
+			newQty = roundQty(newQty + qty)
+			movementCost = unitCost
+			stock.AvgCost = weightedAverageCost(oldQty, stock.AvgCost, qty, unitCost)
 		case models.MovementOut:
 			if oldQty < qty {
-				return errors.New("insufficient stock")
+				return ErrInsufficientStock
 			}
-			newQty -= qty
+			newQty = roundQty(newQty - qty)
 		case models.MovementAdjust:
 			newQty = qty
 		case models.MovementTransfer:
@@ -78,6 +146,9 @@ func (s *StockService) RecordMovement(
 			Qty:       qty,
 			OldValue:  oldQty,
 			NewValue:  newQty,
+			UnitCost:  movementCost,
+			Unit:      unitName,
+			UnitQty:   enteredQty,
 			Reason:    reason,
 			DeviceID:  deviceID,
 		}
@@ -85,23 +156,49 @@ func (s *StockService) RecordMovement(
 			return err
 		}
 
+		// 5. Maintain FIFO cost layers
+		if delta := roundQty(newQty - oldQty); delta > 0 {
+			return openCostLayer(tx, movement, delta)
+		} else if delta < 0 {
+			return consumeCostLayers(tx, shopID, articleID, -delta)
+		}
+
 		return nil
 	})
 
 	return movement, err
 }
 
+// weightedAverageCost returns the unit cost of oldQty units at oldCost merged with qty units at unitCost.
+func weightedAverageCost(oldQty, oldCost, qty, unitCost float64) float64 {
+	if oldQty <= 0 {
+		return unitCost
+	}
+	return (oldQty*oldCost + qty*unitCost) / (oldQty + qty)
+}
+
+// InitiateTransfer takes qty, expressed in unit, out of the source shop. The transfer
+// carries the quantity in the stock unit.
 func (s *StockService) InitiateTransfer(
 	accountID, fromShopID, toShopID, articleID, userID uuid.UUID,
-	qty int,
+	qty float64,
+	unit string,
 	reason, deviceID string,
 ) (*models.StockTransfer, error) {
 	var transfer *models.StockTransfer
 
 	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var isKit bool
+		if err := tx.Model(&models.Article{}).Where("id = ?", articleID).Select("is_kit").Scan(&isKit).Error; err != nil {
+			return err
+		}
+		if isKit {
+			return ErrKitMovement
+		}
+
 		// 1. Exit from source shop (immediate)
 		service := NewStockService(tx)
-		_, err := service.RecordMovement(accountID, fromShopID, articleID, userID, models.MovementOut, qty, "Transfer Out: "+reason, deviceID)
+		out, err := service.RecordMovement(accountID, fromShopID, articleID, userID, models.MovementOut, qty, unit, 0, "Transfer Out: "+reason, deviceID)
 		if err != nil {
 			return err
 		}
@@ -112,7 +209,8 @@ func (s *StockService) InitiateTransfer(
 			FromShopID:  fromShopID,
 			ToShopID:    toShopID,
 			ArticleID:   articleID,
-			Qty:         qty,
+			Qty:         out.Qty,
+			UnitCost:    out.UnitCost,
 			Status:      models.TransferStatusPending,
 			InitiatedBy: userID,
 		}
@@ -142,7 +240,7 @@ func (s *StockService) ReceiveTransfer(
 
 		// 1. Entry to destination shop
 		service := NewStockService(tx)
-		_, err := service.RecordMovement(accountID, transfer.ToShopID, transfer.ArticleID, userID, models.MovementIn, transfer.Qty, "Transfer In (Received)", deviceID)
+		_, err := service.RecordMovement(accountID, transfer.ToShopID, transfer.ArticleID, userID, models.MovementIn, transfer.Qty, "", transfer.UnitCost, "Transfer In (Received)", deviceID)
 		if err != nil {
 			return err
 		}
@@ -178,49 +276,69 @@ func (s *StockService) GetStockLevels(accountID, shopID uuid.UUID) ([]models.Sto
 	return levels, err
 }
 
-func (s *StockService) GetMovements(accountID, shopID, articleID uuid.UUID) ([]models.StockMovement, error) {
+// GetMovements returns the latest movements, or all the movements of a reference
+// (a production order) when it is set.
+func (s *StockService) GetMovements(accountID, shopID, articleID uuid.UUID, reference string) ([]models.StockMovement, error) {
 	var movements []models.StockMovement
 	query := s.DB.Where("account_id = ?", accountID)
+	if reference != "" {
+		query = query.Where("reference = ?", reference)
+	} else {
+		query = query.Limit(20)
+	}
 	if shopID != uuid.Nil {
 		query = query.Where("shop_id = ?", shopID)
 	}
 	if articleID != uuid.Nil {
 		query = query.Where("article_id = ?", articleID)
 	}
-	err := query.Order("created_at desc").Limit(20).Find(&movements).Error
+	err := query.Order("created_at desc").Find(&movements).Error
 	return movements, err
 }
 
+// StockByCategory is the stock of a category, including all of its sub-categories.
 type StockByCategory struct {
-	CategoryName string  `json:"category_name"`
-	TotalValue   float64 `json:"total_value"`
-	ItemCount    int64   `json:"item_count"`
+	CategoryID   *uuid.UUID `json:"category_id"`
+	CategoryName string     `json:"category_name"`
+	TotalValue   float64    `json:"total_value"`
+	ItemCount    int64      `json:"item_count"`
+	HasChildren  bool       `json:"has_children"`
 }
 
 type LowStockItem struct {
-	ArticleName  string `json:"article_name"`
-	Quantity     int    `json:"quantity"`
-	MinThreshold int    `json:"min_threshold"`
-	ShopName     string `json:"shop_name"`
+	ArticleName  string  `json:"article_name"`
+	Quantity     float64 `json:"quantity"`
+	MinThreshold float64 `json:"min_threshold"`
+	StockUnit    string  `json:"stock_unit"`
+	ShopName     string  `json:"shop_name"`
 }
 
 type DailyMovement struct {
-	Date   string `json:"date"`
-	InQty  int    `json:"in_qty"`
-	OutQty int    `json:"out_qty"`
+	Date   string  `json:"date"`
+	InQty  float64 `json:"in_qty"`
+	OutQty float64 `json:"out_qty"`
 }
 
+// stockCostValueSQL values a stock level at its average cost, falling back to the
+// article's CostPrice for stock recorded before costs were tracked.
+const stockCostValueSQL = "stock_levels.quantity * COALESCE(NULLIF(stock_levels.avg_cost, 0), articles.cost_price)"
+
 type DashboardStats struct {
-	TotalStockValue float64           `json:"total_stock_value"`
-	LowStockAlerts  int64             `json:"low_stock_alerts"`
-	TotalArticles   int64             `json:"total_articles"`
-	ActiveShops     int64             `json:"active_shops"`
-	StockByCat      []StockByCategory `json:"stock_by_category"`
-	LowStockItems   []LowStockItem    `json:"low_stock_items"`
-	DailyMovements  []DailyMovement   `json:"daily_movements"`
+	TotalStockValue  float64           `json:"total_stock_value"`  // At cost
+	TotalRetailValue float64           `json:"total_retail_value"` // At selling price
+	LowStockAlerts   int64             `json:"low_stock_alerts"`
+	TotalArticles    int64             `json:"total_articles"`
+	ActiveShops      int64             `json:"active_shops"`
+	StockByCat       []StockByCategory `json:"stock_by_category"`
+	LowStockItems    []LowStockItem    `json:"low_stock_items"`
+	DailyMovements   []DailyMovement   `json:"daily_movements"`
 }
 
-func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*DashboardStats, error) {
+// GetDashboardStats aggregates the stock figures of an account or of one shop. The
+// stock_by_category breakdown lists the root categories, or the direct children of
+// categoryID when set, with the values of their whole subtree rolled up. An unknown
+// categoryID gives gorm.ErrRecordNotFound.
+func (s *StockService) GetDashboardStats(accountID, shopID, categoryID uuid.UUID) (*DashboardStats, error) {
 	var stats DashboardStats
 
 	// 1. Total Articles
@@ -239,7 +357,7 @@ func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*Dashboar
 
 	// 3. Low Stock Alerts & Items
 	queryLow := s.DB.Table("stock_levels").
-		Select("articles.name as article_name, stock_levels.quantity, articles.min_threshold, shops.name as shop_name").
+		Select("articles.name as article_name, stock_levels.quantity, articles.min_threshold, articles.stock_unit, shops.name as shop_name").
 		Joins("JOIN articles ON articles.id = stock_levels.article_id").
 		Joins("JOIN shops ON shops.id = stock_levels.shop_id").
 		Where("articles.account_id = ? AND stock_levels.quantity < articles.min_threshold", accountID)
@@ -255,7 +373,7 @@ func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*Dashboar
 	queryLow.Order("stock_levels.quantity ASC").Limit(10).Scan(&stats.LowStockItems)
 
 	// 4. Total Stock Value & By Category
-	var totalValue float64
+	var totalValue, retailValue sql.NullFloat64
 	queryValue := s.DB.Table("stock_levels").
 		Joins("JOIN articles ON articles.id = stock_levels.article_id").
 		Where("articles.account_id = ?", accountID)
@@ -264,21 +382,16 @@ func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*Dashboar
 		queryValue = queryValue.Where("stock_levels.shop_id = ?", shopID)
 	}
 
-	queryValue.Select("SUM(stock_levels.quantity * articles.price)").Row().Scan(&totalValue)
-	stats.TotalStockValue = totalValue
+	queryValue.Select("SUM("+stockCostValueSQL+"), SUM(stock_levels.quantity * "+shopSellingPriceSQL+")").Row().Scan(&totalValue, &retailValue)
+	stats.TotalStockValue = totalValue.Float64
+	stats.TotalRetailValue = retailValue.Float64
 
 	// Stock By Category
-	queryCat := s.DB.Table("stock_levels").
-		Select("COALESCE(categories.name, 'Non catégorisé') as category_name, SUM(stock_levels.quantity * articles.price) as total_value, COUNT(DISTINCT articles.id) as item_count").
-		Joins("JOIN articles ON articles.id = stock_levels.article_id").
-		Joins("LEFT JOIN categories ON categories.id = articles.category_id").
-		Where("articles.account_id = ?", accountID)
-
-	if shopID != uuid.Nil {
-		queryCat = queryCat.Where("stock_levels.shop_id = ?", shopID)
+	stockByCat, err := s.getStockByCategory(accountID, shopID, categoryID)
+	if err != nil {
+		return nil, err
 	}
-
-	queryCat.Group("categories.name").Scan(&stats.StockByCat)
+	stats.StockByCat = stockByCat
 
 	// 5. Daily Movements (Last 30 days)
 	// We need to group by day. PostgreSQL: to_char(created_at, 'YYYY-MM-DD')
@@ -296,7 +409,7 @@ func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*Dashboar
 		Select("to_char(created_at, 'YYYY-MM-DD') as date, "+
 			"SUM(CASE WHEN type = 'in' THEN qty ELSE 0 END) as in_qty, "+
 			"SUM(CASE WHEN type = 'out' THEN qty ELSE 0 END) as out_qty").
-		Where("account_id = ? AND created_at >= ?", accountID, thirtyDaysAgo).
+		Where("account_id = ? AND created_at >= ? AND kit_movement_id IS NULL", accountID, thirtyDaysAgo).
 		Group("to_char(created_at, 'YYYY-MM-DD')").
 		Order("date ASC")
 
@@ -309,10 +422,123 @@ func (s *StockService) GetDashboardStats(accountID, shopID uuid.UUID) (*Dashboar
 	return &stats, nil
 }
 
+func (s *StockService) getStockByCategory(accountID, shopID, categoryID uuid.UUID) ([]StockByCategory, error) {
+	var categories []models.Category
+	if err := s.DB.Where("account_id = ?", accountID).Order("name asc").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Category, len(categories))
+	hasChildren := make(map[uuid.UUID]bool)
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+		if categories[i].ParentID != nil {
+			hasChildren[*categories[i].ParentID] = true
+		}
+	}
+	if categoryID != uuid.Nil {
+		if _, ok := byID[categoryID]; !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+
+	// Values of the articles directly attached to each category
+	var direct []struct {
+		CategoryID *uuid.UUID
+		TotalValue float64
+		ItemCount  int64
+	}
+	queryCat := s.DB.Table("stock_levels").
+		Select("articles.category_id, SUM("+stockCostValueSQL+") as total_value, COUNT(DISTINCT articles.id) as item_count").
+		Joins("JOIN articles ON articles.id = stock_levels.article_id").
+		Where("articles.account_id = ?", accountID)
+
+	if shopID != uuid.Nil {
+		queryCat = queryCat.Where("stock_levels.shop_id = ?", shopID)
+	}
+	if categoryID != uuid.Nil {
+		queryCat = queryCat.Where("articles.category_id IN ("+models.CategorySubtreeSQL+")", categoryID)
+	}
+
+	if err := queryCat.Group("articles.category_id").Scan(&direct).Error; err != nil {
+		return nil, err
+	}
+
+	// Roll each value up to the entry shown at the requested level: a child of
+	// categoryID (or a root), or categoryID itself for its own articles.
+	entries := make(map[uuid.UUID]*StockByCategory)
+	uncategorized := StockByCategory{CategoryName: "Non catégorisé"}
+	for _, row := range direct {
+		if row.CategoryID == nil || byID[*row.CategoryID] == nil {
+			uncategorized.TotalValue += row.TotalValue
+			uncategorized.ItemCount += row.ItemCount
+			continue
+		}
+
+		entryID := *row.CategoryID
+		for entryID != categoryID {
+			parent := byID[entryID].ParentID
+			if parent == nil || *parent == categoryID || byID[*parent] == nil {
+				break
+			}
+			entryID = *parent
+		}
+
+		entry, ok := entries[entryID]
+		if !ok {
+			id := entryID
+			entry = &StockByCategory{CategoryID: &id, CategoryName: byID[entryID].Name, HasChildren: hasChildren[entryID] && entryID != categoryID}
+			entries[entryID] = entry
+		}
+		entry.TotalValue += row.TotalValue
+		entry.ItemCount += row.ItemCount
+	}
+
+	result := make([]StockByCategory, 0, len(entries)+1)
+	for _, category := range categories {
+		if entry, ok := entries[category.ID]; ok {
+			result = append(result, *entry)
+		}
+	}
+	if uncategorized.ItemCount > 0 {
+		result = append(result, uncategorized)
+	}
+	return result, nil
+}
+
+// RecordSale records an outgoing movement at the selling price resolved for the shop,
+// from the chosen price list when priceListID is set. qty is expressed in unit, the
+// stock unit when empty as for every movement: clients selling by the article's
+// SalesUnit pass it explicitly. Prices are per stock unit.
+func (s *StockService) RecordSale(accountID, shopID, articleID, userID uuid.UUID, qty float64, unit string, priceListID *uuid.UUID, reason, deviceID string) (*models.StockMovement, error) {
+	var movement *models.StockMovement
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		price, err := NewPriceService(tx).ResolvePrice(accountID, articleID, &shopID, priceListID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		movement, err = NewStockService(tx).RecordMovement(accountID, shopID, articleID, userID, models.MovementOut, qty, unit, 0, reason, deviceID)
+		if err != nil {
+			return err
+		}
+
+		movement.UnitPrice = &price.Price
+		movement.PriceListID = price.PriceListID
+		return tx.Model(movement).Select("unit_price", "price_list_id").Updates(movement).Error
+	})
+	return movement, err
+}
+
+// movementPriceSQL is the selling price of a movement: the price recorded with the sale,
+// or Article.Price for movements recorded before prices were.
+const movementPriceSQL = "COALESCE(stock_movements.unit_price, articles.price)"
+
 type SalesStatPoint struct {
 	Label    string  `json:"label"`
 	Revenue  float64 `json:"revenue"`
-	Quantity int     `json:"quantity"`
+	Cost     float64 `json:"cost"`
+	Margin   float64 `json:"margin"`
+	Quantity float64 `json:"quantity"`
 }
 
 func (s *StockService) GetSalesStats(accountID, shopID uuid.UUID, period string) ([]SalesStatPoint, error) {
@@ -345,11 +571,16 @@ func (s *StockService) GetSalesStats(accountID, shopID uuid.UUID, period string)
 
 	query := s.DB.Table("stock_movements").
 		Select("to_char(stock_movements.created_at, ?) as label, "+
-			"SUM(stock_movements.qty * articles.price) as revenue, "+
+			"SUM(stock_movements.qty * "+movementPriceSQL+") as revenue, "+
+			"SUM(stock_movements.qty * stock_movements.unit_cost) as cost, "+
+			"SUM(stock_movements.qty * ("+movementPriceSQL+" - stock_movements.unit_cost)) as margin, "+
 			"SUM(stock_movements.qty) as quantity", dateFormat).
 		Joins("JOIN articles ON articles.id = stock_movements.article_id").
 		Where("stock_movements.account_id = ? AND stock_movements.type = ? AND stock_movements.created_at >= ?",
-			accountID, models.MovementOut, startDate)
+			accountID, models.MovementOut, startDate).
+		// Kit components are left out: a kit sale counts once, at the kit price. So are
+		// production inputs, which are consumed rather than sold.
+		Where("stock_movements.kit_movement_id IS NULL AND COALESCE(stock_movements.reference, '') = ''")
 
 	if shopID != uuid.Nil {
 		query = query.Where("stock_movements.shop_id = ?", shopID)
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"stock_management/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SupplierService struct {
+	DB *gorm.DB
+}
+
+func NewSupplierService(db *gorm.DB) *SupplierService {
+	return &SupplierService{DB: db}
+}
+
+func (s *SupplierService) CreateSupplier(supplier *models.Supplier) error {
+	return s.DB.Create(supplier).Error
+}
+
+// GetSuppliersByAccount lists the account's suppliers, optionally filtered on name, contact or email.
+func (s *SupplierService) GetSuppliersByAccount(accountID uuid.UUID, search string) ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	query := s.DB.Where("account_id = ?", accountID)
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("name ILIKE ? OR contact ILIKE ? OR email ILIKE ?", pattern, pattern, pattern)
+	}
+	err := query.Order("name asc").Find(&suppliers).Error
+	return suppliers, err
+}
+
+func (s *SupplierService) GetSupplier(accountID, supplierID uuid.UUID) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := s.DB.Where("id = ? AND account_id = ?", supplierID, accountID).First(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (s *SupplierService) UpdateSupplier(supplier *models.Supplier) error {
+	return s.DB.Save(supplier).Error
+}
+
+// DeleteSupplier soft-deletes the supplier so it can be restored later.
+func (s *SupplierService) DeleteSupplier(accountID, supplierID uuid.UUID) error {
+	res := s.DB.Where("id = ? AND account_id = ?", supplierID, accountID).Delete(&models.Supplier{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RestoreSupplier clears the soft-delete marker of a previously deleted supplier.
+func (s *SupplierService) RestoreSupplier(accountID, supplierID uuid.UUID) (*models.Supplier, error) {
+	res := s.DB.Unscoped().Model(&models.Supplier{}).
+		Where("id = ? AND account_id = ? AND deleted_at IS NOT NULL", supplierID, accountID).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.GetSupplier(accountID, supplierID)
+}
+
+// GetArticleSuppliers lists the suppliers linked to an article, preferred first.
+func (s *SupplierService) GetArticleSuppliers(accountID, articleID uuid.UUID) ([]models.ArticleSupplier, error) {
+	var links []models.ArticleSupplier
+	err := s.DB.Preload("Supplier").
+		Where("account_id = ? AND article_id = ?", accountID, articleID).
+		Order("is_preferred desc, last_purchase_price asc").
+		Find(&links).Error
+	return links, err
+}
+
+// SaveArticleSupplier creates or updates an article/supplier link and reloads it.
+// The last purchase price, kept up to date by ReceiveOrder, is only changed when
+// setPrice is true. Marking a link as preferred clears the flag on the article's
+// other suppliers.
+func (s *SupplierService) SaveArticleSupplier(link *models.ArticleSupplier, setPrice bool) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Article{}).Where("id = ? AND account_id = ?", link.ArticleID, link.AccountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("article not found")
+		}
+		if err := tx.Model(&models.Supplier{}).Where("id = ? AND account_id = ?", link.SupplierID, link.AccountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("supplier not found")
+		}
+
+		if link.PackSize < 1 {
+			link.PackSize = 1
+		}
+		if link.IsPreferred {
+			if err := tx.Model(&models.ArticleSupplier{}).
+				Where("article_id = ? AND supplier_id <> ?", link.ArticleID, link.SupplierID).
+				Update("is_preferred", false).Error; err != nil {
+				return err
+			}
+		}
+
+		columns := []string{"supplier_ref", "min_order_qty", "pack_size", "lead_time_days", "is_preferred", "updated_at"}
+		if setPrice {
+			columns = append(columns, "last_purchase_price")
+		}
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "article_id"}, {Name: "supplier_id"}},
+			DoUpdates: clause.AssignmentColumns(columns),
+		}).Create(link).Error; err != nil {
+			return err
+		}
+		return tx.Where("article_id = ? AND supplier_id = ?", link.ArticleID, link.SupplierID).First(link).Error
+	})
+}
+
+func (s *SupplierService) RemoveArticleSupplier(accountID, articleID, supplierID uuid.UUID) error {
+	res := s.DB.Where("account_id = ? AND article_id = ? AND supplier_id = ?", accountID, articleID, supplierID).
+		Delete(&models.ArticleSupplier{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"stock_management/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultStockUnit is the stock unit of articles created without one.
+const DefaultStockUnit = "unit"
+
+var (
+	ErrUnknownUnit   = errors.New("unknown unit for this article")
+	ErrInvalidUnit   = errors.New("invalid unit")
+	ErrFractionalQty = errors.New("quantity must be a whole number of stock units")
+)
+
+// roundQty rounds a quantity to the precision of the decimal(14,3) quantity columns.
+func roundQty(qty float64) float64 {
+	return math.Round(qty*1000) / 1000
+}
+
+// unitFactor returns the unit as named on the article and the number of stock units
+// it holds. An empty unit, or the stock unit itself, is the stock unit (factor 1).
+func unitFactor(db *gorm.DB, article *models.Article, unit string) (string, float64, error) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" || strings.EqualFold(unit, article.StockUnit) {
+		return "", 1, nil
+	}
+	var u models.ArticleUnit
+	err := db.Where("article_id = ? AND LOWER(name) = LOWER(?)", article.ID, unit).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", 0, fmt.Errorf("%w: %q", ErrUnknownUnit, unit)
+	} else if err != nil {
+		return "", 0, err
+	}
+	return u.Name, u.Factor, nil
+}
+
+// toStockQty converts qty units of factor into stock units, rejecting fractions
+// of a stock unit for articles that are not sold by weight.
+func toStockQty(article *models.Article, qty, factor float64) (float64, error) {
+	stockQty := roundQty(qty * factor)
+	if !article.DecimalQty && stockQty != math.Trunc(stockQty) {
+		return 0, fmt.Errorf("%w: %s is %g %s", ErrFractionalQty, article.Name, stockQty, article.StockUnit)
+	}
+	return stockQty, nil
+}
+
+// ConvertQty returns qty, expressed in unit, in the stock unit of the article.
+func (s *ArticleService) ConvertQty(accountID, articleID uuid.UUID, unit string, qty float64) (float64, error) {
+	var article models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+		return 0, err
+	}
+	_, factor, err := unitFactor(s.DB, &article, unit)
+	if err != nil {
+		return 0, err
+	}
+	return toStockQty(&article, qty, factor)
+}
+
+func (s *ArticleService) GetArticleUnits(accountID, articleID uuid.UUID) ([]models.ArticleUnit, error) {
+	var units []models.ArticleUnit
+	err := s.DB.Where("account_id = ? AND article_id = ?", accountID, articleID).Order("factor").Find(&units).Error
+	return units, err
+}
+
+// SetArticleUnit adds the unit to the article, or changes its factor when it exists.
+// Past movements keep the stock quantity they were converted to.
+func (s *ArticleService) SetArticleUnit(accountID, articleID uuid.UUID, name string, factor float64) (*models.ArticleUnit, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidUnit)
+	}
+	if factor <= 0 {
+		return nil, fmt.Errorf("%w: factor must be positive", ErrInvalidUnit)
+	}
+
+	var article models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(name, article.StockUnit) {
+		return nil, fmt.Errorf("%w: %q is the stock unit", ErrInvalidUnit, name)
+	}
+
+	var unit models.ArticleUnit
+	err := s.DB.Where("article_id = ? AND LOWER(name) = LOWER(?)", articleID, name).First(&unit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		unit = models.ArticleUnit{AccountID: accountID, ArticleID: articleID, Name: name, Factor: factor}
+		return &unit, s.DB.Create(&unit).Error
+	} else if err != nil {
+		return nil, err
+	}
+	return &unit, s.DB.Model(&unit).Update("factor", factor).Error
+}
+
+// DeleteArticleUnit removes a unit; the article falls back to its stock unit where it
+// was the default purchase or sales unit.
+func (s *ArticleService) DeleteArticleUnit(accountID, articleID, unitID uuid.UUID) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var unit models.ArticleUnit
+		if err := tx.Where("id = ? AND account_id = ? AND article_id = ?", unitID, accountID, articleID).First(&unit).Error; err != nil {
+			return err
+		}
+		for _, column := range []string{"purchase_unit", "sales_unit"} {
+			if err := tx.Model(&models.Article{}).Where("id = ? AND "+column+" = ?", articleID, unit.Name).
+				Update(column, "").Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&unit).Error
+	})
+}
+
+// UnitSettings are the unit of measure fields of an article.
+type UnitSettings struct {
+	StockUnit    string
+	DecimalQty   bool
+	PurchaseUnit string
+	SalesUnit    string
+}
+
+// UpdateUnitSettings renames the stock unit and sets the default purchase and sales
+// units, which must be units of the article. Quantities are not converted: renaming
+// "unit" to "kg" does not change the stock held.
+func (s *ArticleService) UpdateUnitSettings(accountID, articleID uuid.UUID, settings UnitSettings) (*models.Article, error) {
+	var article models.Article
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND account_id = ?", articleID, accountID).First(&article).Error; err != nil {
+			return err
+		}
+
+		stockUnit := strings.TrimSpace(settings.StockUnit)
+		if stockUnit == "" {
+			stockUnit = DefaultStockUnit
+		}
+		var clash int64
+		if err := tx.Model(&models.ArticleUnit{}).Where("article_id = ? AND LOWER(name) = LOWER(?)", articleID, stockUnit).
+			Count(&clash).Error; err != nil {
+			return err
+		}
+		if clash > 0 {
+			return fmt.Errorf("%w: %q is already an alternative unit", ErrInvalidUnit, stockUnit)
+		}
+		article.StockUnit = stockUnit
+
+		if article.DecimalQty && !settings.DecimalQty {
+			var fractional int64
+			if err := tx.Model(&models.StockLevel{}).Where("article_id = ? AND quantity <> TRUNC(quantity)", articleID).
+				Count(&fractional).Error; err != nil {
+				return err
+			}
+			if fractional > 0 {
+				return fmt.Errorf("%w: stock of %s holds fractional quantities", ErrInvalidUnit, article.Name)
+			}
+		}
+		article.DecimalQty = settings.DecimalQty
+
+		var err error
+		if article.PurchaseUnit, _, err = unitFactor(tx, &article, settings.PurchaseUnit); err != nil {
+			return err
+		}
+		if article.SalesUnit, _, err = unitFactor(tx, &article, settings.SalesUnit); err != nil {
+			return err
+		}
+
+		return tx.Model(&article).Select("stock_unit", "decimal_qty", "purchase_unit", "sales_unit").Updates(&article).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"stock_management/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ValuationMethod string
+
+const (
+	ValuationFIFO    ValuationMethod = "fifo"
+	ValuationAverage ValuationMethod = "average"
+)
+
+// openCostLayer records qty units entering stock at the movement's unit cost.
+func openCostLayer(tx *gorm.DB, movement *models.StockMovement, qty float64) error {
+	layer := &models.CostLayer{
+		AccountID:    movement.AccountID,
+		ShopID:       movement.ShopID,
+		ArticleID:    movement.ArticleID,
+		MovementID:   movement.ID,
+		UnitCost:     movement.UnitCost,
+		InitialQty:   qty,
+		RemainingQty: qty,
+	}
+	return tx.Create(layer).Error
+}
+
+// consumeCostLayers takes qty units out of the oldest open layers. Stock that
+// predates cost layers has no layer to consume, so any excess is ignored.
+func consumeCostLayers(tx *gorm.DB, shopID, articleID uuid.UUID, qty float64) error {
+	var layers []models.CostLayer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("shop_id = ? AND article_id = ? AND remaining_qty > 0", shopID, articleID).
+		Order("created_at asc").
+		Find(&layers).Error; err != nil {
+		return err
+	}
+
+	for _, layer := range layers {
+		if qty <= 0 {
+			break
+		}
+		taken := min(qty, layer.RemainingQty)
+		if err := tx.Model(&models.CostLayer{}).Where("id = ?", layer.ID).
+			Update("remaining_qty", roundQty(layer.RemainingQty-taken)).Error; err != nil {
+			return err
+		}
+		qty = roundQty(qty - taken)
+	}
+	return nil
+}
+
+// GetCostLayers lists the open FIFO layers of a shop, oldest first.
+func (s *StockService) GetCostLayers(accountID, shopID, articleID uuid.UUID) ([]models.CostLayer, error) {
+	var layers []models.CostLayer
+	query := s.DB.Where("account_id = ? AND remaining_qty > 0", accountID)
+	if shopID != uuid.Nil {
+		query = query.Where("shop_id = ?", shopID)
+	}
+	if articleID != uuid.Nil {
+		query = query.Where("article_id = ?", articleID)
+	}
+	err := query.Order("created_at asc").Find(&layers).Error
+	return layers, err
+}
+
+type ValuationLine struct {
+	ShopID      uuid.UUID `json:"shop_id"`
+	ShopName    string    `json:"shop_name"`
+	ArticleID   uuid.UUID `json:"article_id"`
+	ArticleCode string    `json:"article_code"`
+	ArticleName string    `json:"article_name"`
+	Quantity    float64   `json:"quantity"`
+	UnitCost    float64   `json:"unit_cost"`
+	Value       float64   `json:"value"`
+}
+
+type ShopValuation struct {
+	ShopID   uuid.UUID       `json:"shop_id"`
+	ShopName string          `json:"shop_name"`
+	Value    float64         `json:"value"`
+	Lines    []ValuationLine `json:"lines"`
+}
+
+type ValuationReport struct {
+	Method     ValuationMethod `json:"method"`
+	Date       time.Time       `json:"date"`
+	TotalValue float64         `json:"total_value"`
+	Shops      []ShopValuation `json:"shops"`
+}
+
+type valuationLayer struct {
+	qty  float64
+	cost float64
+}
+
+// valuationState replays the movements of one article in one shop.
+type valuationState struct {
+	qty     float64
+	avgCost float64
+	layers  []valuationLayer
+}
+
+func (v *valuationState) apply(m *models.StockMovement) {
+	delta := roundQty(m.NewValue - m.OldValue)
+	if delta > 0 {
+		v.avgCost = weightedAverageCost(v.qty, v.avgCost, delta, m.UnitCost)
+		v.layers = append(v.layers, valuationLayer{qty: delta, cost: m.UnitCost})
+	} else if delta < 0 {
+		remaining := -delta
+		for remaining > 0 && len(v.layers) > 0 {
+			taken := min(remaining, v.layers[0].qty)
+			v.layers[0].qty = roundQty(v.layers[0].qty - taken)
+			remaining = roundQty(remaining - taken)
+			if v.layers[0].qty <= 0 {
+				v.layers = v.layers[1:]
+			}
+		}
+	}
+	v.qty = m.NewValue
+}
+
+func (v *valuationState) value(method ValuationMethod) float64 {
+	if method == ValuationAverage {
+		return v.qty * v.avgCost
+	}
+
+	// Units with no layer (stock that predates cost tracking) are valued at average cost
+	total := 0.0
+	layered := 0.0
+	for _, layer := range v.layers {
+		total += layer.qty * layer.cost
+		layered += layer.qty
+	}
+	if v.qty > layered {
+		total += (v.qty - layered) * v.avgCost
+	}
+	return total
+}
+
+// GetValuationReport values the stock of every shop (or of shopID only) as it
+// stood at date, by replaying the stock_movements history in FIFO or average mode.
+func (s *StockService) GetValuationReport(accountID, shopID uuid.UUID, date time.Time, method ValuationMethod) (*ValuationReport, error) {
+	if method != ValuationFIFO && method != ValuationAverage {
+		return nil, errors.New("invalid valuation method")
+	}
+
+	query := s.DB.Model(&models.StockMovement{}).
+		Where("account_id = ? AND created_at <= ?", accountID, date)
+	if shopID != uuid.Nil {
+		query = query.Where("shop_id = ?", shopID)
+	}
+
+	rows, err := query.Order("shop_id, article_id, created_at asc").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type stockKey struct{ shopID, articleID uuid.UUID }
+	states := make(map[stockKey]*valuationState)
+	var keys []stockKey
+	for rows.Next() {
+		var movement models.StockMovement
+		if err := s.DB.ScanRows(rows, &movement); err != nil {
+			return nil, err
+		}
+		key := stockKey{movement.ShopID, movement.ArticleID}
+		state, ok := states[key]
+		if !ok {
+			state = &valuationState{}
+			states[key] = state
+			keys = append(keys, key)
+		}
+		state.apply(&movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &ValuationReport{Method: method, Date: date, Shops: []ShopValuation{}}
+	if len(keys) == 0 {
+		return report, nil
+	}
+
+	shopNames := make(map[uuid.UUID]string)
+	var shops []models.Shop
+	if err := s.DB.Unscoped().Where("account_id = ?", accountID).Find(&shops).Error; err != nil {
+		return nil, err
+	}
+	for _, shop := range shops {
+		shopNames[shop.ID] = shop.Name
+	}
+
+	articles := make(map[uuid.UUID]models.Article)
+	var articleList []models.Article
+	if err := s.DB.Unscoped().Where("account_id = ?", accountID).Find(&articleList).Error; err != nil {
+		return nil, err
+	}
+	for _, article := range articleList {
+		articles[article.ID] = article
+	}
+
+	shopIndex := make(map[uuid.UUID]int)
+	for _, key := range keys {
+		state := states[key]
+		if state.qty <= 0 {
+			continue
+		}
+
+		idx, ok := shopIndex[key.shopID]
+		if !ok {
+			idx = len(report.Shops)
+			shopIndex[key.shopID] = idx
+			report.Shops = append(report.Shops, ShopValuation{ShopID: key.shopID, ShopName: shopNames[key.shopID]})
+		}
+
+		value := state.value(method)
+		article := articles[key.articleID]
+		report.Shops[idx].Lines = append(report.Shops[idx].Lines, ValuationLine{
+			ShopID:      key.shopID,
+			ShopName:    shopNames[key.shopID],
+			ArticleID:   key.articleID,
+			ArticleCode: article.Code,
+			ArticleName: article.Name,
+			Quantity:    state.qty,
+			UnitCost:    value / state.qty,
+			Value:       value,
+		})
+		report.Shops[idx].Value += value
+		report.TotalValue += value
+	}
+
+	return report, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stock_management/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrVariantParent = errors.New("stock is held by the variants of this product")
+
+// VariantStock is a variant with its stock in each shop.
+type VariantStock struct {
+	models.Article
+	StockByShop map[uuid.UUID]float64 `json:"stock_by_shop"`
+}
+
+// ProductWithVariants groups a parent product with its variant matrix.
+type ProductWithVariants struct {
+	Product    models.Article `json:"product"`
+	Sizes      []string       `json:"sizes"`
+	Colors     []string       `json:"colors"`
+	Variants   []VariantStock `json:"variants"`
+	TotalStock float64        `json:"total_stock"`
+}
+
+// CreateVariant adds a variant under a parent product. Fields left empty on the
+// variant are inherited from the parent.
+func (s *ArticleService) CreateVariant(parentID uuid.UUID, variant *models.Article, initialStock float64, shopID *uuid.UUID, userID uuid.UUID) error {
+	var parent models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", parentID, variant.AccountID).First(&parent).Error; err != nil {
+		return err
+	}
+	if parent.ParentID != nil {
+		return errors.New("a variant cannot have variants of its own")
+	}
+
+	variant.Size = strings.TrimSpace(variant.Size)
+	variant.Color = strings.TrimSpace(variant.Color)
+	if variant.Size == "" && variant.Color == "" {
+		return errors.New("a variant needs a size or a colour")
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Article{}).
+		Where("parent_id = ? AND LOWER(size) = LOWER(?) AND LOWER(color) = LOWER(?)", parent.ID, variant.Size, variant.Color).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("variant %s already exists", variantLabel(variant.Size, variant.Color))
+	}
+
+	if stock, err := s.variantParentStock(parent.ID); err != nil {
+		return err
+	} else if stock > 0 {
+		return errors.New("move the stock of the product to its variants before adding variants")
+	}
+
+	variant.ParentID = &parent.ID
+	variant.Name = parent.Name + " - " + variantLabel(variant.Size, variant.Color)
+	variant.Description = parent.Description
+	variant.CategoryID = parent.CategoryID
+	variant.BrandID = parent.BrandID
+	variant.ImageURL = parent.ImageURL
+	variant.ThumbnailURL = parent.ThumbnailURL
+	variant.StockUnit = parent.StockUnit
+	variant.DecimalQty = parent.DecimalQty
+	attributes := make(models.Attributes, len(parent.Attributes)+len(variant.Attributes))
+	for key, value := range parent.Attributes {
+		attributes[key] = value
+	}
+	for key, value := range variant.Attributes {
+		attributes[key] = value
+	}
+	variant.Attributes = attributes
+	if !variant.HasPriceOverride {
+		variant.Price = parent.Price
+	}
+
+	return s.CreateArticle(variant, initialStock, shopID, userID)
+}
+
+// GenerateVariants creates one variant per size/colour combination, skipping those that already exist.
+func (s *ArticleService) GenerateVariants(accountID, parentID uuid.UUID, sizes, colors []string, userID uuid.UUID) ([]models.Article, error) {
+	if len(sizes) == 0 {
+		sizes = []string{""}
+	}
+	if len(colors) == 0 {
+		colors = []string{""}
+	}
+
+	var parent models.Article
+	if err := s.DB.Where("id = ? AND account_id = ?", parentID, accountID).First(&parent).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []models.Article
+	if err := s.DB.Where("parent_id = ?", parentID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		exists[strings.ToLower(v.Size+"|"+v.Color)] = true
+	}
+
+	var created []models.Article
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		service := NewArticleService(tx)
+		for _, size := range sizes {
+			for _, color := range colors {
+				size, color := strings.TrimSpace(size), strings.TrimSpace(color)
+				if (size == "" && color == "") || exists[strings.ToLower(size+"|"+color)] {
+					continue
+				}
+				variant := &models.Article{
+					AccountID:    accountID,
+					Size:         size,
+					Color:        color,
+					MinThreshold: parent.MinThreshold,
+					CostPrice:    parent.CostPrice,
+				}
+				if err := service.CreateVariant(parentID, variant, 0, nil, userID); err != nil {
+					return err
+				}
+				exists[strings.ToLower(size+"|"+color)] = true
+				created = append(created, *variant)
+			}
+		}
+		return nil
+	})
+	return created, err
+}
+
+// GetProductsWithVariants lists the parent products of the account with their
+// variant matrix and the stock of each variant per shop (or in shopID only).
+func (s *ArticleService) GetProductsWithVariants(accountID uuid.UUID, shopID *uuid.UUID) ([]ProductWithVariants, error) {
+	var products []models.Article
+	if err := s.DB.Where("account_id = ? AND parent_id IS NULL AND EXISTS (SELECT 1 FROM articles v WHERE v.parent_id = articles.id AND v.deleted_at IS NULL)", accountID).
+		Order("name asc").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return []ProductWithVariants{}, nil
+	}
+
+	productIDs := make([]uuid.UUID, len(products))
+	for i, p := range products {
+		productIDs[i] = p.ID
+	}
+
+	var variants []models.Article
+	if err := s.DB.Where("parent_id IN ?", productIDs).Order("size asc, color asc").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+
+	variantIDs := make([]uuid.UUID, len(variants))
+	for i, v := range variants {
+		variantIDs[i] = v.ID
+	}
+	var levels []models.StockLevel
+	levelQuery := s.DB.Where("article_id IN ?", variantIDs)
+	if shopID != nil {
+		levelQuery = levelQuery.Where("shop_id = ?", *shopID)
+	}
+	if err := levelQuery.Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	stock := make(map[uuid.UUID]map[uuid.UUID]float64)
+	for _, level := range levels {
+		if stock[level.ArticleID] == nil {
+			stock[level.ArticleID] = make(map[uuid.UUID]float64)
+		}
+		stock[level.ArticleID][level.ShopID] = level.Quantity
+	}
+
+	byParent := make(map[uuid.UUID][]models.Article)
+	for _, v := range variants {
+		byParent[*v.ParentID] = append(byParent[*v.ParentID], v)
+	}
+
+	result := make([]ProductWithVariants, 0, len(products))
+	for _, product := range products {
+		group := ProductWithVariants{Product: product, Sizes: []string{}, Colors: []string{}, Variants: []VariantStock{}}
+		seenSize := make(map[string]bool)
+		seenColor := make(map[string]bool)
+		for _, v := range byParent[product.ID] {
+			if v.Size != "" && !seenSize[v.Size] {
+				seenSize[v.Size] = true
+				group.Sizes = append(group.Sizes, v.Size)
+			}
+			if v.Color != "" && !seenColor[v.Color] {
+				seenColor[v.Color] = true
+				group.Colors = append(group.Colors, v.Color)
+			}
+
+			byShop := stock[v.ID]
+			if byShop == nil {
+				byShop = map[uuid.UUID]float64{}
+			}
+			v.TotalStock = 0
+			for _, qty := range byShop {
+				v.TotalStock = roundQty(v.TotalStock + qty)
+			}
+			group.TotalStock = roundQty(group.TotalStock + v.TotalStock)
+			group.Variants = append(group.Variants, VariantStock{Article: v, StockByShop: byShop})
+		}
+		group.Product.TotalStock = group.TotalStock
+		result = append(result, group)
+	}
+	return result, nil
+}
+
+// syncVariantPrices copies a parent's price to its variants that do not override it.
+func syncVariantPrices(tx *gorm.DB, parent *models.Article, userID uuid.UUID) error {
+	var variants []models.Article
+	if err := tx.Where("parent_id = ? AND has_price_override = ? AND price <> ?", parent.ID, false, parent.Price).
+		Find(&variants).Error; err != nil {
+		return err
+	}
+
+	for i := range variants {
+		oldPrice := variants[i].Price
+		variants[i].Price = parent.Price
+		if err := recordPriceChange(tx, &variants[i], oldPrice, userID, models.PriceChangeVariant, nil); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Article{}).
+		Where("parent_id = ? AND has_price_override = ?", parent.ID, false).
+		Update("price", parent.Price).Error
+}
+
+// variantParentStock returns the stock held directly by an article across shops.
+func (s *ArticleService) variantParentStock(articleID uuid.UUID) (float64, error) {
+	var total float64
+	err := s.DB.Model(&models.StockLevel{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("article_id = ?", articleID).Scan(&total).Error
+	return total, err
+}
+
+func variantLabel(size, color string) string {
+	switch {
+	case size == "":
+		return color
+	case color == "":
+		return size
+	}
+	return size + " / " + color
+}
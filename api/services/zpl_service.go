@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrinterPort is the raw printing port of Zebra-compatible printers.
+const DefaultPrinterPort = "9100"
+
+// ZPLOptions configures the thermal label size and printer resolution.
+type ZPLOptions struct {
+	WidthMM  float64
+	HeightMM float64
+	DPI      int // 203, 300 or 600
+}
+
+func (o *ZPLOptions) normalize() error {
+	if o.WidthMM == 0 {
+		o.WidthMM = 50
+	}
+	if o.HeightMM == 0 {
+		o.HeightMM = 30
+	}
+	if o.DPI == 0 {
+		o.DPI = 203
+	}
+	if o.DPI != 203 && o.DPI != 300 && o.DPI != 600 {
+		return errors.New("dpi must be 203, 300 or 600")
+	}
+	if o.WidthMM < 20 || o.WidthMM > 120 || o.HeightMM < 15 || o.HeightMM > 200 {
+		return errors.New("label size must be between 20x15 mm and 120x200 mm")
+	}
+	return nil
+}
+
+func (o *ZPLOptions) dots(mm float64) int {
+	return int(math.Round(mm * float64(o.DPI) / 25.4))
+}
+
+// RenderZPL produces a ZPL II document for the labels. Consecutive identical
+// labels are printed once with a ^PQ quantity.
+func (s *LabelService) RenderZPL(labels []Label, opts ZPLOptions) (string, error) {
+	if err := opts.normalize(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(labels); {
+		count := 1
+		for i+count < len(labels) && labels[i+count] == labels[i] {
+			count++
+		}
+		if err := writeZPLLabel(&b, labels[i], count, &opts); err != nil {
+			return "", err
+		}
+		i += count
+	}
+	return b.String(), nil
+}
+
+func writeZPLLabel(b *strings.Builder, label Label, copies int, opts *ZPLOptions) error {
+	width, height := opts.dots(opts.WidthMM), opts.dots(opts.HeightMM)
+	margin := opts.dots(2)
+	nameH := clampInt(height/8, opts.dots(2.5), opts.dots(4))
+	priceH := clampInt(height/5, opts.dots(3), opts.dots(7))
+	textH := nameH * 4 / 5
+
+	bc, err := EncodeBarcode(label.Code, label.Symbology)
+	if err != nil {
+		return fmt.Errorf("article %q: %w", label.Name, err)
+	}
+	module := clampInt((width-2*margin)/bc.Bounds().Dx(), 1, opts.DPI/100)
+	barcodeX := (width - module*bc.Bounds().Dx()) / 2
+	barcodeY := margin + nameH + priceH + margin
+	barcodeH := height - barcodeY - textH - 2*margin
+	if barcodeH < opts.dots(5) {
+		return errors.New("label too small for a barcode")
+	}
+
+	b.WriteString("^XA\n^CI28\n")
+	fmt.Fprintf(b, "^PW%d\n^LL%d\n", width, height)
+
+	// Article name, on a single line and truncated by the printer at the label edge
+	fmt.Fprintf(b, "^FO%d,%d^A0N,%d,%d^FB%d,1,0,L^FH_^FD%s^FS\n", margin, margin, nameH, nameH, width-2*margin, zplEscape(label.Name))
+	fmt.Fprintf(b, "^FO%d,%d^A0N,%d,%d^FH_^FD%s^FS\n", margin, margin+nameH, priceH, priceH, zplEscape(FormatPrice(label.Price)))
+
+	fmt.Fprintf(b, "^BY%d,2,%d\n", module, barcodeH)
+	if label.Symbology == SymbologyEAN13 {
+		// ^BE computes the check digit itself from the first 12 digits
+		fmt.Fprintf(b, "^FO%d,%d^BEN,%d,Y,N^FD%s^FS\n", barcodeX, barcodeY, barcodeH, label.Code[:12])
+	} else {
+		fmt.Fprintf(b, "^FO%d,%d^BCN,%d,Y,N,N^FH_^FD%s^FS\n", barcodeX, barcodeY, barcodeH, zplEscape(label.Code))
+	}
+
+	if copies > 1 {
+		fmt.Fprintf(b, "^PQ%d\n", copies)
+	}
+	b.WriteString("^XZ\n")
+	return nil
+}
+
+// zplEscape hex-encodes the characters ZPL treats as commands (used with ^FH_).
+func zplEscape(s string) string {
+	r := strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E")
+	return r.Replace(s)
+}
+
+// SendToPrinter streams a ZPL document to a printer over raw TCP. The port
+// defaults to 9100 when address has none.
+func (s *LabelService) SendToPrinter(address, zpl string, timeout time.Duration) error {
+	address, err := NormalizePrinterAddress(address)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Errorf("printer unreachable: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte(zpl)); err != nil {
+		return fmt.Errorf("failed to send labels to printer: %w", err)
+	}
+	return nil
+}
+
+// NormalizePrinterAddress validates a host[:port] printer address and adds the default port.
+func NormalizePrinterAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", errors.New("printer address is required")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, DefaultPrinterPort
+	}
+	if host == "" {
+		return "", errors.New("invalid printer address")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", errors.New("invalid printer port")
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// CheckLANPrinterAddress accepts only printer addresses given as an IP of a private
+// network (10/8, 172.16/12, 192.168/16, fc00::/7) or a loopback IP (127/8, ::1, for a
+// printer shared by the server machine or a local test listener). Hostnames are refused
+// so the address cannot be redirected elsewhere between the check and the connection.
+func CheckLANPrinterAddress(address string) (string, error) {
+	address, err := NormalizePrinterAddress(address)
+	if err != nil {
+		return "", err
+	}
+	host, _, _ := net.SplitHostPort(address)
+	ip := net.ParseIP(host)
+	if ip == nil || !(ip.IsPrivate() || ip.IsLoopback()) {
+		return "", errors.New("printer address must be an IP address of the local network")
+	}
+	return address, nil
+}
+
+// TestLabel is the label sent by printer connection tests.
+func TestLabel() Label {
+	return Label{Name: "Test imprimante", Price: 0, Code: "TEST-9100", Symbology: SymbologyCode128}
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
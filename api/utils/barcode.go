@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type BarcodeType string
+
+const (
+	BarcodeEAN13   BarcodeType = "ean13"
+	BarcodeEAN8    BarcodeType = "ean8"
+	BarcodeUPCA    BarcodeType = "upca"
+	BarcodeCode128 BarcodeType = "code128"
+)
+
+// GTINCheckDigit computes the GS1 check digit of the given digits (without check digit).
+func GTINCheckDigit(digits string) (int, error) {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		ch := digits[i]
+		if ch < '0' || ch > '9' {
+			return 0, errors.New("barcode must only contain digits")
+		}
+		d := int(ch - '0')
+		// Weights alternate 3, 1, 3... starting from the rightmost digit
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// ValidateGTIN checks the length and check digit of an EAN-13, EAN-8 or UPC-A code.
+func ValidateGTIN(code string, length int) error {
+	if len(code) != length {
+		return fmt.Errorf("barcode must have %d digits", length)
+	}
+	check, err := GTINCheckDigit(code[:length-1])
+	if err != nil {
+		return err
+	}
+	if int(code[length-1]-'0') != check {
+		return fmt.Errorf("invalid check digit, expected %d", check)
+	}
+	return nil
+}
+
+func ValidateEAN13(code string) error { return ValidateGTIN(code, 13) }
+func ValidateEAN8(code string) error  { return ValidateGTIN(code, 8) }
+func ValidateUPCA(code string) error  { return ValidateGTIN(code, 12) }
+
+// DetectBarcode infers the type of a scanned code and validates it. Numeric codes
+// of 8, 12 or 13 digits must carry a valid GS1 check digit; any other printable
+// ASCII code is accepted as Code 128.
+func DetectBarcode(code string) (BarcodeType, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", errors.New("barcode is empty")
+	}
+
+	if isDigits(code) {
+		switch len(code) {
+		case 13:
+			return BarcodeEAN13, ValidateEAN13(code)
+		case 12:
+			return BarcodeUPCA, ValidateUPCA(code)
+		case 8:
+			return BarcodeEAN8, ValidateEAN8(code)
+		}
+	}
+
+	for _, r := range code {
+		if r < 32 || r > 126 {
+			return "", errors.New("barcode contains characters that cannot be encoded")
+		}
+	}
+	return BarcodeCode128, nil
+}
+
+// BuildEAN13 builds an EAN-13 code from a numeric prefix and a sequence number.
+func BuildEAN13(prefix string, sequence int64) (string, error) {
+	width := 12 - len(prefix)
+	if !isDigits(prefix) || width < 1 {
+		return "", errors.New("invalid barcode prefix")
+	}
+	body := fmt.Sprintf("%s%0*d", prefix, width, sequence)
+	if len(body) != 12 {
+		return "", errors.New("barcode sequence exhausted for this prefix")
+	}
+	check, _ := GTINCheckDigit(body)
+	return fmt.Sprintf("%s%d", body, check), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
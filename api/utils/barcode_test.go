@@ -0,0 +1,82 @@
+package utils
+
+import "testing"
+
+func TestGTINCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   int
+	}{
+		{"400638133393", 1}, // EAN-13 4006381333931
+		{"590123412345", 7}, // EAN-13 5901234123457
+		{"03600029145", 2},  // UPC-A 036000291452
+		{"9638507", 4},      // EAN-8 96385074
+		{"200000000042", 8},
+		{"000000000000", 0},
+	}
+	for _, tt := range tests {
+		got, err := GTINCheckDigit(tt.digits)
+		if err != nil || got != tt.want {
+			t.Errorf("GTINCheckDigit(%q) = %d, %v, want %d", tt.digits, got, err, tt.want)
+		}
+	}
+
+	if _, err := GTINCheckDigit("40063813339A"); err == nil {
+		t.Error("GTINCheckDigit accepted a non-digit")
+	}
+}
+
+func TestDetectBarcode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    BarcodeType
+		wantErr bool
+	}{
+		{"4006381333931", BarcodeEAN13, false},
+		{" 5901234123457 ", BarcodeEAN13, false},
+		{"4006381333932", BarcodeEAN13, true},
+		{"036000291452", BarcodeUPCA, false},
+		{"036000291453", BarcodeUPCA, true},
+		{"96385074", BarcodeEAN8, false},
+		{"96385075", BarcodeEAN8, true},
+		{"ABC-123", BarcodeCode128, false},
+		{"1234567", BarcodeCode128, false}, // Not a GTIN length
+		{"", "", true},
+		{"café", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DetectBarcode(tt.code)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DetectBarcode(%q) = %q, %v, want %q (error: %v)", tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBuildEAN13(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		sequence int64
+		want     string
+		wantErr  bool
+	}{
+		{"200", 42, "2000000000428", false},
+		{"2991234", 1, "2991234000011", false},
+		{"29", 9999999999, "2999999999991", false},
+		{"29", 10000000000, "", true}, // Sequence exhausted
+		{"", 1, "", true},
+		{"2A", 1, "", true},
+		{"123456789012", 1, "", true}, // No room for the sequence
+	}
+	for _, tt := range tests {
+		got, err := BuildEAN13(tt.prefix, tt.sequence)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("BuildEAN13(%q, %d) = %q, %v, want %q (error: %v)", tt.prefix, tt.sequence, got, err, tt.want, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if err := ValidateEAN13(got); err != nil {
+				t.Errorf("BuildEAN13(%q, %d) = %q: %v", tt.prefix, tt.sequence, got, err)
+			}
+		}
+	}
+}